@@ -0,0 +1,184 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+// Package awserr classifies AWS API errors and backs off a source that keeps
+// failing, e.g. while IRSA credentials are being refreshed.
+package awserr
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+)
+
+// Class of an AWS error, ordered by severity.
+type Class int
+
+const (
+	None Class = iota
+	Other
+	NotFound
+	Throttling
+	AccessDenied
+)
+
+func (c Class) String() string {
+	switch c {
+	case None:
+		return "none"
+	case NotFound:
+		return "not_found"
+	case Throttling:
+		return "throttling"
+	case AccessDenied:
+		return "access_denied"
+	}
+	return "other"
+}
+
+var (
+	accessDeniedCodes = []string{
+		"AccessDenied", "AccessDeniedException", "UnauthorizedOperation", "AuthFailure",
+		"UnrecognizedClientException", "InvalidClientTokenId", "InvalidAccessKeyId",
+		"SignatureDoesNotMatch", "ExpiredToken", "ExpiredTokenException",
+	}
+	throttlingCodes = []string{
+		"Throttling", "ThrottlingException", "ThrottledException", "RequestLimitExceeded",
+		"RequestThrottled", "RequestThrottledException", "TooManyRequestsException", "SlowDown",
+	}
+)
+
+// Classify returns the most severe class of err, which may join several errors.
+func Classify(err error) Class {
+	if err == nil {
+		return None
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		class := None
+		for _, err := range joined.Unwrap() {
+			class = max(class, Classify(err))
+		}
+		return class
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.ErrorCode()
+		switch {
+		case slices.Contains(accessDeniedCodes, code):
+			return AccessDenied
+		case slices.Contains(throttlingCodes, code):
+			return Throttling
+		case strings.HasSuffix(code, "NotFound") || strings.HasSuffix(code, "NotFoundFault") || strings.HasSuffix(code, "NotFoundException"):
+			return NotFound
+		}
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return AccessDenied
+		case http.StatusTooManyRequests:
+			return Throttling
+		case http.StatusNotFound:
+			return NotFound
+		}
+	}
+	return Other
+}
+
+// State of a source, as exported in metrics.
+const (
+	StateOK           = "ok"
+	StateThrottled    = "throttled"
+	StateAccessDenied = "access_denied"
+	StateError        = "error"
+)
+
+// States lists every state, to reset the ones a source is not in.
+var States = []string{StateOK, StateThrottled, StateAccessDenied, StateError}
+
+// Backoff delays, doubled for every consecutive failure.
+const (
+	throttlingBaseDelay   = 30 * time.Second
+	throttlingMaxDelay    = 10 * time.Minute
+	accessDeniedBaseDelay = time.Minute
+	accessDeniedMaxDelay  = 30 * time.Minute
+)
+
+// Status is a snapshot of a Backoff.
+type Status struct {
+	State     string    `json:"state"`
+	Failures  int       `json:"failures"`
+	RetryAt   time.Time `json:"retryAt"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// Backoff tracks the errors of a source. Throttling and access denied errors delay
+// the next attempt exponentially, any success resets it.
+type Backoff struct {
+	mu       sync.Mutex
+	state    string
+	failures int
+	retryAt  time.Time
+	lastErr  error
+}
+
+func NewBackoff() *Backoff {
+	return &Backoff{state: StateOK}
+}
+
+// Allow reports whether the source may call AWS at now.
+func (b *Backoff) Allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.retryAt)
+}
+
+// Observe records the outcome of a call and returns its class. Missing resources
+// are expected while instances come and go, they do not change the state.
+func (b *Backoff) Observe(err error, now time.Time) Class {
+	class := Classify(err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch class {
+	case None, NotFound:
+		b.state, b.failures, b.retryAt, b.lastErr = StateOK, 0, time.Time{}, nil
+	case Throttling:
+		b.fail(StateThrottled, err, now, throttlingBaseDelay, throttlingMaxDelay)
+	case AccessDenied:
+		b.fail(StateAccessDenied, err, now, accessDeniedBaseDelay, accessDeniedMaxDelay)
+	default:
+		// e.g. network errors, retried on the next refresh
+		b.state, b.failures, b.retryAt, b.lastErr = StateError, b.failures+1, time.Time{}, err
+	}
+	return class
+}
+
+func (b *Backoff) fail(state string, err error, now time.Time, base, limit time.Duration) {
+	if b.state != state {
+		b.failures = 0
+	}
+	b.state, b.lastErr = state, err
+	b.failures++
+	delay := base << min(b.failures-1, 16)
+	b.retryAt = now.Add(min(delay, limit))
+}
+
+func (b *Backoff) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := Status{State: b.state, Failures: b.failures, RetryAt: b.retryAt}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	return status
+}
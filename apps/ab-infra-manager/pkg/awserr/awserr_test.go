@@ -0,0 +1,149 @@
+package awserr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func apiError(code string) error {
+	return &smithy.GenericAPIError{Code: code, Message: "test"}
+}
+
+func responseError(status int, err error) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      err,
+		},
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{"nil", nil, None},
+		{"access denied", apiError("AccessDenied"), AccessDenied},
+		{"unauthorized operation", apiError("UnauthorizedOperation"), AccessDenied},
+		{"expired token", apiError("ExpiredTokenException"), AccessDenied},
+		{"throttling", apiError("Throttling"), Throttling},
+		{"request limit exceeded", apiError("RequestLimitExceeded"), Throttling},
+		{"too many requests", apiError("TooManyRequestsException"), Throttling},
+		{"db instance not found", apiError("DBInstanceNotFound"), NotFound},
+		{"cache cluster not found", apiError("CacheClusterNotFoundFault"), NotFound},
+		{"resource not found", apiError("ResourceNotFoundException"), NotFound},
+		{"unknown code", apiError("InvalidParameterValue"), Other},
+		{"http 401", responseError(http.StatusUnauthorized, errors.New("no body")), AccessDenied},
+		{"http 403", responseError(http.StatusForbidden, errors.New("no body")), AccessDenied},
+		{"http 429", responseError(http.StatusTooManyRequests, errors.New("no body")), Throttling},
+		{"http 404", responseError(http.StatusNotFound, errors.New("no body")), NotFound},
+		{"http 500", responseError(http.StatusInternalServerError, errors.New("no body")), Other},
+		{"code wins over status", responseError(http.StatusBadRequest, apiError("ThrottlingException")), Throttling},
+		{"wrapped", fmt.Errorf("describe instances: %w", apiError("AuthFailure")), AccessDenied},
+		{"wrapped http", fmt.Errorf("get metric data: %w", responseError(http.StatusTooManyRequests, errors.New("no body"))), Throttling},
+		{"joined", errors.Join(errors.New("dial tcp: timeout"), apiError("SlowDown"), apiError("DBClusterNotFoundFault")), Throttling},
+		{"joined most severe", errors.Join(apiError("Throttling"), fmt.Errorf("rds: %w", apiError("AccessDeniedException"))), AccessDenied},
+		{"joined unknown", errors.Join(errors.New("a"), errors.New("b")), Other},
+		{"unknown", errors.New("dial tcp: i/o timeout"), Other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	throttled := apiError("Throttling")
+	denied := apiError("AccessDenied")
+
+	type step struct {
+		err      error
+		state    string
+		failures int
+		delay    time.Duration // 0 when the next attempt is not delayed
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"throttling grows to the cap", []step{
+			{throttled, StateThrottled, 1, 30 * time.Second},
+			{throttled, StateThrottled, 2, time.Minute},
+			{throttled, StateThrottled, 3, 2 * time.Minute},
+			{throttled, StateThrottled, 4, 4 * time.Minute},
+			{throttled, StateThrottled, 5, 8 * time.Minute},
+			{throttled, StateThrottled, 6, 10 * time.Minute},
+			{throttled, StateThrottled, 7, 10 * time.Minute},
+		}},
+		{"access denied grows to the cap", []step{
+			{denied, StateAccessDenied, 1, time.Minute},
+			{denied, StateAccessDenied, 2, 2 * time.Minute},
+			{denied, StateAccessDenied, 3, 4 * time.Minute},
+			{denied, StateAccessDenied, 4, 8 * time.Minute},
+			{denied, StateAccessDenied, 5, 16 * time.Minute},
+			{denied, StateAccessDenied, 6, 30 * time.Minute},
+		}},
+		{"success resets", []step{
+			{throttled, StateThrottled, 1, 30 * time.Second},
+			{throttled, StateThrottled, 2, time.Minute},
+			{nil, StateOK, 0, 0},
+			{throttled, StateThrottled, 1, 30 * time.Second},
+		}},
+		{"not found resets", []step{
+			{denied, StateAccessDenied, 1, time.Minute},
+			{apiError("DBInstanceNotFound"), StateOK, 0, 0},
+		}},
+		{"new class restarts the count", []step{
+			{throttled, StateThrottled, 1, 30 * time.Second},
+			{throttled, StateThrottled, 2, time.Minute},
+			{denied, StateAccessDenied, 1, time.Minute},
+		}},
+		{"other errors are not delayed", []step{
+			{errors.New("dial tcp: i/o timeout"), StateError, 1, 0},
+			{errors.New("dial tcp: i/o timeout"), StateError, 2, 0},
+			{nil, StateOK, 0, 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff()
+			at := now
+			for i, s := range tt.steps {
+				b.Observe(s.err, at)
+				status := b.Status()
+				if status.State != s.state || status.Failures != s.failures {
+					t.Fatalf("step %d: state %q failures %d, want %q %d", i, status.State, status.Failures, s.state, s.failures)
+				}
+				if s.delay == 0 {
+					if !b.Allow(at) {
+						t.Fatalf("step %d: not allowed right away", i)
+					}
+					continue
+				}
+				if got := status.RetryAt.Sub(at); got != s.delay {
+					t.Fatalf("step %d: delay %v, want %v", i, got, s.delay)
+				}
+				if b.Allow(status.RetryAt.Add(-time.Second)) {
+					t.Fatalf("step %d: allowed before %v", i, status.RetryAt)
+				}
+				if !b.Allow(status.RetryAt) {
+					t.Fatalf("step %d: not allowed at %v", i, status.RetryAt)
+				}
+				at = status.RetryAt
+			}
+		})
+	}
+}
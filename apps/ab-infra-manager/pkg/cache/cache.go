@@ -5,75 +5,211 @@
 package cache
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
 
-type item[V any] struct {
+type item[K comparable, V any] struct {
+	key    K
 	value  V
 	expiry time.Time
 }
 
-func (i *item[V]) isExpired() bool {
-	return time.Now().After(i.expiry)
+func (i *item[K, V]) isExpired(now time.Time) bool {
+	return now.After(i.expiry)
 }
 
+// Options configure a cache, the zero value is an unbounded cache without metrics
+// whose expired items are only evicted when read.
+type Options struct {
+	// Name labels the metrics of the cache.
+	Name string
+	// MaxEntries evicts the least recently used items beyond it, unlimited when 0.
+	MaxEntries int
+	// JanitorInterval is how often expired items are evicted in the background,
+	// never when 0. The janitor runs until Close.
+	JanitorInterval time.Duration
+	// Metrics counts hits, misses and evictions, optional.
+	Metrics *Metrics
+}
+
+// Cache is a map whose items expire after their own TTL.
 type Cache[K comparable, V any] struct {
-	items        map[K]item[V]
-	mu           sync.Mutex
-	globalExpiry time.Time
+	opts Options
+	mu   sync.Mutex
+	// items by key, elements of lru from the most to the least recently used
+	items map[K]*list.Element
+	lru   *list.List
+
+	loadMu sync.Mutex
+	loads  map[K]*load[V]
+	stop   chan struct{}
+	closed sync.Once
 }
 
-func New[K comparable, V any]() *Cache[K, V] {
-	return &Cache[K, V]{
-		items: make(map[K]item[V]),
-	}
+// load is a GetOrLoad in progress, concurrent calls for its key wait for it.
+type load[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
 }
 
-func (c *Cache[K, V]) IsExpired() bool {
-	return time.Now().After(c.globalExpiry)
+func New[K comparable, V any](opts Options) *Cache[K, V] {
+	c := &Cache[K, V]{
+		opts:  opts,
+		items: make(map[K]*list.Element),
+		lru:   list.New(),
+		loads: make(map[K]*load[V]),
+		stop:  make(chan struct{}),
+	}
+	if opts.JanitorInterval > 0 {
+		go c.janitor(opts.JanitorInterval)
+	}
+	return c
 }
 
 func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.items[key] = item[V]{
-		value:  value,
-		expiry: time.Now().Add(ttl),
+	i := &item[K, V]{key: key, value: value, expiry: time.Now().Add(ttl)}
+	if e, ok := c.items[key]; ok {
+		e.Value = i
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.lru.PushFront(i)
+	if c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back(), "capacity")
 	}
-	c.globalExpiry = time.Now().Add(ttl)
 }
 
 func (c *Cache[K, V]) Get(key K) (V, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	i, ok := c.items[key]
-
+	var zero V
+	e, ok := c.items[key]
 	if !ok {
-		return i.value, false
+		c.opts.Metrics.miss(c.opts.Name)
+		return zero, false
+	}
+	i := e.Value.(*item[K, V])
+	if i.isExpired(time.Now()) {
+		c.remove(e, "expired")
+		c.opts.Metrics.miss(c.opts.Name)
+		return zero, false
 	}
+	c.lru.MoveToFront(e)
+	c.opts.Metrics.hit(c.opts.Name)
+	return i.value, true
+}
 
-	if c.IsExpired() {
-		delete(c.items, key)
-		return i.value, false
+// GetOrLoad returns the item of key, or calls loader and caches its value for ttl.
+// Concurrent calls for the same key wait for a single loader and share its result.
+// Errors are returned to every waiting call and not cached.
+func (c *Cache[K, V]) GetOrLoad(key K, ttl time.Duration, loader func() (V, error)) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
 	}
 
-	if i.isExpired() {
-		delete(c.items, key)
-		return i.value, false
+	c.loadMu.Lock()
+	if l, ok := c.loads[key]; ok {
+		c.loadMu.Unlock()
+		<-l.done
+		return l.value, l.err
 	}
+	l := &load[V]{done: make(chan struct{})}
+	c.loads[key] = l
+	c.loadMu.Unlock()
 
-	return i.value, true
+	defer func() {
+		c.loadMu.Lock()
+		delete(c.loads, key)
+		c.loadMu.Unlock()
+		close(l.done)
+	}()
+	l.value, l.err = loader()
+	if l.err == nil {
+		c.Set(key, l.value, ttl)
+	}
+	return l.value, l.err
 }
 
+// Length returns the number of items that have not expired.
 func (c *Cache[K, V]) Length() int {
-	return len(c.items)
+	return len(c.Keys())
 }
 
+// Keys returns the keys of the items that have not expired.
 func (c *Cache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
 	keys := make([]K, 0, len(c.items))
-	for k := range c.items {
-		keys = append(keys, k)
+	for k, e := range c.items {
+		if !e.Value.(*item[K, V]).isExpired(now) {
+			keys = append(keys, k)
+		}
 	}
 	return keys
 }
+
+// Items returns a copy of the items that have not expired.
+func (c *Cache[K, V]) Items() map[K]V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	items := make(map[K]V, len(c.items))
+	for k, e := range c.items {
+		if i := e.Value.(*item[K, V]); !i.isExpired(now) {
+			items[k] = i.value
+		}
+	}
+	return items
+}
+
+// Clear removes every item, so the next Get misses and the data is fetched again.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[K]*list.Element)
+	c.lru.Init()
+}
+
+// Close stops the janitor.
+func (c *Cache[K, V]) Close() {
+	c.closed.Do(func() { close(c.stop) })
+}
+
+// janitor evicts expired items every interval, so items that are not read again
+// do not stay in memory.
+func (c *Cache[K, V]) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.evictExpired()
+		}
+	}
+}
+
+func (c *Cache[K, V]) evictExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for _, e := range c.items {
+		if e.Value.(*item[K, V]).isExpired(now) {
+			c.remove(e, "expired")
+		}
+	}
+}
+
+// remove deletes the element e, c.mu must be held.
+func (c *Cache[K, V]) remove(e *list.Element, reason string) {
+	c.lru.Remove(e)
+	delete(c.items, e.Value.(*item[K, V]).key)
+	c.opts.Metrics.evict(c.opts.Name, reason)
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stored returns the number of items held, expired or not.
+func (c *Cache[K, V]) stored() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+func newTestCache(t *testing.T, opts Options) (*Cache[string, int], *Metrics) {
+	t.Helper()
+	opts.Name = "test"
+	opts.Metrics = NewMetrics(prometheus.NewRegistry())
+	c := New[string, int](opts)
+	t.Cleanup(c.Close)
+	return c, opts.Metrics
+}
+
+func TestExpiry(t *testing.T) {
+	c, m := newTestCache(t, Options{})
+	c.Set("short", 1, time.Millisecond)
+	c.Set("long", 2, time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Error("expired item was returned")
+	}
+	if value, ok := c.Get("long"); !ok || value != 2 {
+		t.Errorf("Get(long) = %d, %v", value, ok)
+	}
+	if keys := c.Keys(); !slices.Equal(keys, []string{"long"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+	if got := testutil.ToFloat64(m.evictions.WithLabelValues("test", "expired")); got != 1 {
+		t.Errorf("expired evictions = %v, want 1", got)
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	c, m := newTestCache(t, Options{MaxEntries: 2})
+	c.Set("a", 1, time.Hour)
+	c.Set("b", 2, time.Hour)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", 3, time.Hour)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used item was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	if got := testutil.ToFloat64(m.evictions.WithLabelValues("test", "capacity")); got != 1 {
+		t.Errorf("capacity evictions = %v, want 1", got)
+	}
+
+	// replacing an item does not evict another one
+	c.Set("a", 4, time.Hour)
+	if c.Length() != 2 {
+		t.Errorf("Length() = %d, want 2", c.Length())
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	c, _ := newTestCache(t, Options{JanitorInterval: time.Millisecond})
+	c.Set("a", 1, time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for c.stored() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("janitor did not evict the expired item")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Close()
+	c.Close() // closing twice is allowed
+	time.Sleep(5 * time.Millisecond)
+	c.Set("b", 1, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if c.stored() != 1 {
+		t.Error("janitor still runs after Close")
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c, m := newTestCache(t, Options{})
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]int, 10)
+	for i := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], _ = c.GetOrLoad("key", time.Hour, loader)
+		}()
+	}
+	// every call has missed the cache before the loader returns
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(m.misses.WithLabelValues("test")) < float64(len(values)) {
+		if time.Now().After(deadline) {
+			t.Fatal("calls did not reach the loader")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("loader called %d times, want 1", calls.Load())
+	}
+	for i, value := range values {
+		if value != 42 {
+			t.Errorf("call %d got %d", i, value)
+		}
+	}
+	if value, err := c.GetOrLoad("key", time.Hour, loader); err != nil || value != 42 {
+		t.Errorf("cached GetOrLoad() = %d, %v", value, err)
+	}
+	if calls.Load() != 1 {
+		t.Error("loader called for a cached item")
+	}
+	if got := testutil.ToFloat64(m.hits.WithLabelValues("test")); got != 1 {
+		t.Errorf("hits = %v, want 1", got)
+	}
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	c, _ := newTestCache(t, Options{})
+	failure := errors.New("throttled")
+	if _, err := c.GetOrLoad("key", time.Hour, func() (int, error) { return 0, failure }); !errors.Is(err, failure) {
+		t.Fatalf("GetOrLoad() error = %v", err)
+	}
+	// errors are not cached
+	value, err := c.GetOrLoad("key", time.Hour, func() (int, error) { return 1, nil })
+	if err != nil || value != 1 {
+		t.Errorf("GetOrLoad() = %d, %v", value, err)
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	c := New[string, int](Options{MaxEntries: 1})
+	c.Set("a", 1, time.Hour)
+	c.Set("b", 2, time.Hour)
+	c.Get("a")
+	c.Get("b")
+	if c.Length() != 1 {
+		t.Errorf("Length() = %d, want 1", c.Length())
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics count the hits, misses and evictions of every cache, by cache name.
+type Metrics struct {
+	hits      *prometheus.CounterVec
+	misses    *prometheus.CounterVec
+	evictions *prometheus.CounterVec
+}
+
+// NewMetrics registers the cache counters on reg, they are shared by all caches.
+func NewMetrics(reg *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "cache",
+			Name:      "hits_total",
+			Help:      "Reads of a cached item that had not expired",
+		}, []string{"cache"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "cache",
+			Name:      "misses_total",
+			Help:      "Reads of a missing or expired item",
+		}, []string{"cache"}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "cache",
+			Name:      "evictions_total",
+			Help:      "Items removed because they expired or the cache was full",
+		}, []string{"cache", "reason"}),
+	}
+	reg.MustRegister(m.hits, m.misses, m.evictions)
+	return m
+}
+
+// hit, miss and evict accept a nil Metrics, for caches without metrics.
+
+func (m *Metrics) hit(name string) {
+	if m != nil {
+		m.hits.WithLabelValues(name).Inc()
+	}
+}
+
+func (m *Metrics) miss(name string) {
+	if m != nil {
+		m.misses.WithLabelValues(name).Inc()
+	}
+}
+
+func (m *Metrics) evict(name, reason string) {
+	if m != nil {
+		m.evictions.WithLabelValues(name, reason).Inc()
+	}
+}
@@ -3,9 +3,13 @@ package config
 import (
 	"accelbyte/ab-infra-manager/pkg/k8s"
 	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/ownership"
+	"accelbyte/ab-infra-manager/pkg/persistence"
 	"fmt"
 	"log/slog"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,24 +19,10 @@ import (
 func Load() (models.Cfg, error) {
 	c := models.Cfg{}
 
-	ccu, err := strconv.Atoi(os.Getenv("CCU"))
+	err := parse(&c, os.Getenv)
 	if err != nil {
-		return c, fmt.Errorf("CCU environment variable must be set")
+		return c, err
 	}
-	c.CCU = int64(ccu)
-
-	live, err := strconv.ParseBool(os.Getenv("LIVE"))
-	if err != nil {
-		return c, fmt.Errorf("LIVE environment variable must be set")
-	}
-	c.Live = live
-
-	ksResources := os.Getenv("KUSTOMIZATION_RESOURCES")
-	if ksResources == "" {
-		return c, fmt.Errorf("KUSTOMIZATION_RESOURCES environment variable must be set with comma separated")
-	}
-	s := strings.Split(ksResources, ",")
-	c.KsResources = s
 
 	ksNamespace := os.Getenv("KUSTOMIZATION_NAMESPACE")
 	if ksNamespace == "" {
@@ -41,19 +31,42 @@ func Load() (models.Cfg, error) {
 	}
 	c.KsNamespace = ksNamespace
 
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "INFO"
+	// the ConfigMap this pod reads its environment from, watched for live reloads
+	c.ConfigMapNamespace = os.Getenv("POD_NAMESPACE")
+	if c.ConfigMapNamespace == "" {
+		c.ConfigMapNamespace = "justice"
 	}
-	slogLevel := &slog.LevelVar{}
-	err = slogLevel.UnmarshalText([]byte(logLevel))
-	if err != nil {
-		return c, fmt.Errorf("invalid log level")
+	c.ConfigMapName = os.Getenv("CONFIGMAP_NAME")
+	if c.ConfigMapName == "" {
+		c.ConfigMapName = "ab-infra-manager"
+	}
+
+	// bearer token for the write API, the endpoints are disabled without it
+	c.APIToken = os.Getenv("API_TOKEN")
+	c.AuditConfigMapName = os.Getenv("AUDIT_CONFIGMAP_NAME")
+	if c.AuditConfigMapName == "" {
+		c.AuditConfigMapName = "ab-infra-manager-audit"
 	}
-	c.LogLevel = int(slogLevel.Level())
 
 	c.AWSProfile = os.Getenv("AWS_PROFILE")
 
+	// usage snapshots survive restarts in a BoltDB file on a volume, a ConfigMap or a Secret
+	c.Persistence.Backend = os.Getenv("USAGE_PERSISTENCE")
+	if c.Persistence.Backend == "" {
+		c.Persistence.Backend = persistence.BackendNone
+	}
+	if !slices.Contains(persistence.Backends, c.Persistence.Backend) {
+		return c, fmt.Errorf("USAGE_PERSISTENCE must be one of %s", strings.Join(persistence.Backends, ", "))
+	}
+	c.Persistence.Path = os.Getenv("USAGE_PERSISTENCE_PATH")
+	if c.Persistence.Path == "" {
+		c.Persistence.Path = "/data/usage.db"
+	}
+	c.Persistence.Name = os.Getenv("USAGE_PERSISTENCE_NAME")
+	if c.Persistence.Name == "" {
+		c.Persistence.Name = "ab-infra-manager-usage"
+	}
+
 	k8sConfig, err := k8s.GetKubeConfig()
 	if err != nil {
 		return c, fmt.Errorf("error getting k8s config")
@@ -67,25 +80,233 @@ func Load() (models.Cfg, error) {
 		return c, fmt.Errorf("error initializing k8s dynamic client set")
 	}
 
-	awsUsageScrapeIntervalEnv := os.Getenv("AWS_USAGE_SCRAPE_INTERVAL")
+	return c, nil
+}
+
+// Reload returns a copy of current with the settings of the ab-infra-manager ConfigMap
+// applied. Keys missing from the ConfigMap fall back to the environment. An invalid
+// update returns an error and leaves current untouched.
+func Reload(current models.Cfg, data map[string]string) (models.Cfg, error) {
+	c := current
+	err := parse(&c, func(key string) string {
+		if value, ok := data[key]; ok {
+			return value
+		}
+		return os.Getenv(key)
+	})
+	if err != nil {
+		return current, err
+	}
+	return c, nil
+}
+
+// parse reads the settings that can change at runtime.
+func parse(c *models.Cfg, getenv func(string) string) error {
+	ccu, err := strconv.Atoi(getenv("CCU"))
+	if err != nil {
+		return fmt.Errorf("CCU environment variable must be set")
+	}
+	if ccu < 0 {
+		return fmt.Errorf("CCU must not be negative")
+	}
+	c.CCU = int64(ccu)
+
+	live, err := strconv.ParseBool(getenv("LIVE"))
+	if err != nil {
+		return fmt.Errorf("LIVE environment variable must be set")
+	}
+	c.Live = live
+
+	// Kustomizations are discovered by label or annotation, KUSTOMIZATION_RESOURCES
+	// optionally tracks additional ones by name.
+	c.KsResources = nil
+	ksResources := getenv("KUSTOMIZATION_RESOURCES")
+	if ksResources != "" {
+		c.KsResources = strings.Split(ksResources, ",")
+	}
+
+	ksTrackKey := getenv("KUSTOMIZATION_TRACK_KEY")
+	if ksTrackKey == "" {
+		ksTrackKey = "ab-infra-manager/track"
+	}
+	c.KsTrackKey = ksTrackKey
+
+	// one ab_infra_manager_<kustomization> gauge per component, kept during the
+	// migration to the ab_infra_manager_component_* families
+	legacyMetricsEnv := getenv("LEGACY_KUSTOMIZATION_METRICS")
+	if legacyMetricsEnv == "" {
+		legacyMetricsEnv = "false"
+	}
+	legacyMetrics, err := strconv.ParseBool(legacyMetricsEnv)
+	if err != nil {
+		return fmt.Errorf("error parsing LEGACY_KUSTOMIZATION_METRICS")
+	}
+	c.LegacyMetrics = legacyMetrics
+
+	logLevel := getenv("LOG_LEVEL")
+	if logLevel == "" {
+		logLevel = "INFO"
+	}
+	slogLevel := &slog.LevelVar{}
+	err = slogLevel.UnmarshalText([]byte(logLevel))
+	if err != nil {
+		return fmt.Errorf("invalid log level")
+	}
+	c.LogLevel = int(slogLevel.Level())
+
+	awsUsageScrapeIntervalEnv := getenv("AWS_USAGE_SCRAPE_INTERVAL")
 	if awsUsageScrapeIntervalEnv == "" {
 		awsUsageScrapeIntervalEnv = "24h"
 	}
 	awsUsageScrapeInterval, err := time.ParseDuration(awsUsageScrapeIntervalEnv)
-	if err != nil {
-		return c, fmt.Errorf("error parsing AWS_USAGE_SCRAPE_INTERVAL")
+	if err != nil || awsUsageScrapeInterval <= 0 {
+		return fmt.Errorf("error parsing AWS_USAGE_SCRAPE_INTERVAL")
 	}
 	c.AWSUsageScrapeInterval = awsUsageScrapeInterval
 
-	awsUsageTimeRangeEnv := os.Getenv("AWS_USAGE_TIME_RANGE")
+	awsUsageTimeRangeEnv := getenv("AWS_USAGE_TIME_RANGE")
 	if awsUsageTimeRangeEnv == "" {
 		awsUsageTimeRangeEnv = "336h"
 	}
 	awsUsageTimeRange, err := time.ParseDuration(awsUsageTimeRangeEnv)
-	if err != nil {
-		return c, fmt.Errorf("error parsing AWS_USAGE_TIME_RANGE")
+	if err != nil || awsUsageTimeRange <= 0 {
+		return fmt.Errorf("error parsing AWS_USAGE_TIME_RANGE")
 	}
 	c.AWSUsageTimeRange = awsUsageTimeRange
 
-	return c, nil
+	awsUsagePeriodEnv := getenv("AWS_USAGE_PERIOD")
+	if awsUsagePeriodEnv == "" {
+		awsUsagePeriodEnv = "1h"
+	}
+	awsUsagePeriod, err := time.ParseDuration(awsUsagePeriodEnv)
+	if err != nil || awsUsagePeriod < time.Minute || awsUsagePeriod%time.Minute != 0 {
+		return fmt.Errorf("AWS_USAGE_PERIOD must be a multiple of 1m")
+	}
+	c.AWSUsagePeriod = awsUsagePeriod
+
+	awsUsageStatisticsEnv := getenv("AWS_USAGE_STATISTICS")
+	if awsUsageStatisticsEnv == "" {
+		awsUsageStatisticsEnv = "Maximum"
+	}
+	c.AWSUsageStatistics = nil
+	for _, statistic := range strings.Split(awsUsageStatisticsEnv, ",") {
+		statistic = strings.TrimSpace(statistic)
+		if !isStatistic(statistic) {
+			return fmt.Errorf("invalid statistic %q in AWS_USAGE_STATISTICS, use Average, Maximum, Minimum or a percentile like p95", statistic)
+		}
+		if !slices.Contains(c.AWSUsageStatistics, statistic) {
+			c.AWSUsageStatistics = append(c.AWSUsageStatistics, statistic)
+		}
+	}
+
+	durations := []struct {
+		env      string
+		fallback time.Duration
+		value    *time.Duration
+	}{
+		{"AWS_SUBNET_REFRESH_INTERVAL", 5 * time.Minute, &c.AWSSubnetRefreshInterval},
+		{"AWS_USAGE_REFRESH_INTERVAL", 5 * time.Minute, &c.AWSUsageRefreshInterval},
+		{"AWS_COMPUTE_REFRESH_INTERVAL", 5 * time.Minute, &c.AWSComputeRefreshInterval},
+		{"NODE_CAPACITY_REFRESH_INTERVAL", time.Minute, &c.NodeCapacityRefreshInterval},
+		{"AWS_SCRAPE_TIMEOUT", 2 * time.Minute, &c.AWSScrapeTimeout},
+		{"AWS_SUBNET_FORECAST_WINDOW", 7 * 24 * time.Hour, &c.AWSSubnetForecastWindow},
+	}
+	for _, d := range durations {
+		*d.value = d.fallback
+		if value := getenv(d.env); value != "" {
+			*d.value, err = time.ParseDuration(value)
+			if err != nil || *d.value <= 0 {
+				return fmt.Errorf("error parsing %s", d.env)
+			}
+		}
+	}
+
+	requiredTags := getenv("OWNERSHIP_REQUIRED_TAGS")
+	if requiredTags == "" {
+		requiredTags = "customer_name,project,environment_name"
+	}
+	c.Ownership.RequiredTags = nil
+	for _, tag := range strings.Split(requiredTags, ",") {
+		tag = strings.TrimSpace(tag)
+		key, value, explicit := strings.Cut(tag, "=")
+		if key == "" || (explicit && value == "") || (!explicit && !slices.Contains(clusterVariableTags, key)) {
+			return fmt.Errorf("invalid tag %q in OWNERSHIP_REQUIRED_TAGS, use one of %s or key=value", tag, strings.Join(clusterVariableTags, ", "))
+		}
+		c.Ownership.RequiredTags = append(c.Ownership.RequiredTags, tag)
+	}
+
+	c.Ownership.NamePattern = getenv("OWNERSHIP_NAME_PATTERN")
+	if c.Ownership.NamePattern == "" {
+		// the environment between dashes, so dev does not match devtest
+		c.Ownership.NamePattern = "(^|-)" + ownership.EnvironmentPlaceholder + "(-|$)"
+	}
+	if _, err := regexp.Compile(strings.ReplaceAll(c.Ownership.NamePattern, ownership.EnvironmentPlaceholder, "environment")); err != nil {
+		return fmt.Errorf("error parsing OWNERSHIP_NAME_PATTERN: %w", err)
+	}
+	nameFallback := getenv("OWNERSHIP_NAME_FALLBACK")
+	if nameFallback == "" {
+		nameFallback = "true"
+	}
+	c.Ownership.NameFallback, err = strconv.ParseBool(nameFallback)
+	if err != nil {
+		return fmt.Errorf("error parsing OWNERSHIP_NAME_FALLBACK")
+	}
+
+	usageHistory := getenv("USAGE_HISTORY")
+	if usageHistory == "" {
+		usageHistory = "14"
+	}
+	c.UsageHistory, err = strconv.Atoi(usageHistory)
+	if err != nil || c.UsageHistory < 1 {
+		return fmt.Errorf("USAGE_HISTORY must be a positive number of windows")
+	}
+
+	c.Recommendation.Statistic = getenv("RECOMMENDATION_STATISTIC")
+	if c.Recommendation.Statistic == "" {
+		c.Recommendation.Statistic = "Maximum"
+	}
+	if !slices.Contains(c.AWSUsageStatistics, c.Recommendation.Statistic) {
+		return fmt.Errorf("RECOMMENDATION_STATISTIC %s must be one of AWS_USAGE_STATISTICS", c.Recommendation.Statistic)
+	}
+
+	thresholds := []struct {
+		env      string
+		fallback float64
+		value    *float64
+	}{
+		{"RECOMMENDATION_CPU_LOW", 30, &c.Recommendation.CPULow},
+		{"RECOMMENDATION_CPU_HIGH", 80, &c.Recommendation.CPUHigh},
+		{"RECOMMENDATION_MEMORY_LOW", 40, &c.Recommendation.MemoryLow},
+		{"RECOMMENDATION_MEMORY_HIGH", 85, &c.Recommendation.MemoryHigh},
+	}
+	for _, t := range thresholds {
+		*t.value = t.fallback
+		if value := getenv(t.env); value != "" {
+			*t.value, err = strconv.ParseFloat(value, 64)
+			if err != nil || *t.value < 0 || *t.value > 100 {
+				return fmt.Errorf("%s must be a percentage", t.env)
+			}
+		}
+	}
+	if c.Recommendation.CPULow >= c.Recommendation.CPUHigh || c.Recommendation.MemoryLow >= c.Recommendation.MemoryHigh {
+		return fmt.Errorf("recommendation low thresholds must be below the high thresholds")
+	}
+
+	return nil
+}
+
+// clusterVariableTags can be required by key only, their value comes from cluster-variables.
+var clusterVariableTags = []string{"customer_name", "project", "environment_name"}
+
+// isStatistic reports whether CloudWatch accepts s as a statistic, e.g. Maximum or p99.9.
+func isStatistic(s string) bool {
+	if slices.Contains(models.StandardStatistics, s) {
+		return true
+	}
+	percentile, ok := strings.CutPrefix(s, "p")
+	if !ok {
+		return false
+	}
+	value, err := strconv.ParseFloat(percentile, 64)
+	return err == nil && value > 0 && value <= 100
 }
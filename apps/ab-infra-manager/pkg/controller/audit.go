@@ -0,0 +1,85 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package controller
+
+import (
+	"accelbyte/ab-infra-manager/pkg/k8s"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxAuditEntries bounds the audit log, in memory and in its ConfigMap.
+const maxAuditEntries = 500
+
+// appendAudit records entry in the audit log, a.mu must be held.
+func (a *App) appendAudit(entry models.AuditEntry) {
+	a.audit = append(a.audit, entry)
+	if len(a.audit) > maxAuditEntries {
+		a.audit = a.audit[len(a.audit)-maxAuditEntries:]
+	}
+}
+
+// auditKey is the ConfigMap key holding the audit log, as a JSON array.
+const auditKey = "audit.json"
+
+// persistAudit writes the audit log to its ConfigMap as readable JSON, so it
+// survives restarts and can be read with kubectl. A failure is logged, the setting
+// is already applied.
+func (a *App) persistAudit(ctx context.Context) {
+	cfg := a.cfg()
+	if cfg.AuditConfigMapName == "" {
+		return
+	}
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+	data, err := json.MarshalIndent(a.auditEntries(), "", "  ")
+	if err != nil {
+		slog.Error("failed to encode the audit log", slog.Any("Error", err))
+		return
+	}
+	err = k8s.PatchConfigmap(ctx, cfg.K8sClientSet, cfg.ConfigMapNamespace, cfg.AuditConfigMapName, map[string]string{auditKey: string(data)})
+	if err != nil {
+		slog.Error("failed to persist the audit log", slog.Any("ConfigMap", cfg.AuditConfigMapName), slog.Any("Error", err))
+	}
+}
+
+// restoreAudit loads the audit log kept by the previous pods.
+func (a *App) restoreAudit(ctx context.Context) error {
+	cfg := a.cfg()
+	if cfg.AuditConfigMapName == "" {
+		return nil
+	}
+	configMap, err := cfg.K8sClientSet.CoreV1().ConfigMaps(cfg.ConfigMapNamespace).Get(ctx, cfg.AuditConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	var entries []models.AuditEntry
+	if data := configMap.Data[auditKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			return fmt.Errorf("error reading %s of %s: %w", auditKey, cfg.AuditConfigMapName, err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.audit = append(entries, a.audit...)
+	if len(a.audit) > maxAuditEntries {
+		a.audit = a.audit[len(a.audit)-maxAuditEntries:]
+	}
+	return nil
+}
+
+// auditEntries returns a copy of the audit log, oldest first.
+func (a *App) auditEntries() []models.AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.audit)
+}
@@ -1,26 +1,54 @@
 package controller
 
 import (
+	"accelbyte/ab-infra-manager/pkg/awserr"
+	"accelbyte/ab-infra-manager/pkg/cache"
 	"accelbyte/ab-infra-manager/pkg/config"
 	"accelbyte/ab-infra-manager/pkg/k8s"
 	"accelbyte/ab-infra-manager/pkg/metrics"
 	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/persistence"
 	"context"
 	"log/slog"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	toolscache "k8s.io/client-go/tools/cache"
 )
 
 type App struct {
-	router  *gin.Engine
-	config  *models.Cfg
+	router *gin.Engine
+	// config is replaced as a whole on reload and never modified, readers load it
+	// once and work on that snapshot
+	config  atomic.Pointer[models.Cfg]
 	metrics map[string]*metrics.Metrics
 	ch      *chan bool
+	stopCh  chan struct{}
 	promReg *prometheus.Registry
 	state   models.AppState
+
+	// tracked kustomizations, by name
+	mu               sync.Mutex
+	ksInformer       toolscache.SharedIndexInformer
+	components       map[string]models.Kustomization
+	componentMetrics *metrics.ComponentMetrics
+
+	usageCollector *metrics.AWSUsageCollector
+	refreshers     []*metrics.Refresher
+	configMetrics  *metrics.ConfigMetrics
+	reload         models.ReloadStatus
+	audit          []models.AuditEntry
+	// serializes the writes of the audit ConfigMap, the last one holds the newest log
+	auditMu sync.Mutex
+
+	// last successful read of cluster-variables, for readiness
+	lastStateUpdate time.Time
+	stateErr        error
 }
 
 func (a *App) Init() {
@@ -32,10 +60,10 @@ func (a *App) Init() {
 		slog.Error(err.Error())
 		os.Exit(1)
 	}
-	a.config = &c
+	a.config.Store(&c)
 	slog.SetLogLoggerLevel(slog.Level(c.LogLevel))
 
-	cvars, err := k8s.GetConfigmap(a.config.K8sClientSet, "default", "cluster-variables")
+	cvars, err := k8s.GetConfigmap(c.K8sClientSet, "default", "cluster-variables")
 	if err != nil {
 		slog.Error("error getting cluster-variables configmap")
 		os.Exit(1)
@@ -44,7 +72,7 @@ func (a *App) Init() {
 	slog.Info("configure aws sdk")
 	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background(),
 		awsconfig.WithRegion(cvars["AWS_REGION"]),
-		awsconfig.WithSharedConfigProfile(a.config.AWSProfile),
+		awsconfig.WithSharedConfigProfile(c.AWSProfile),
 	)
 	if err != nil {
 		slog.Error("unable to load SDK config", "error", err)
@@ -53,34 +81,125 @@ func (a *App) Init() {
 
 	// prometheus & metrics stuff
 	a.promReg = prometheus.NewRegistry()
-	a.metrics = metrics.Init(a.promReg, a.config.KsResources)
+	a.metrics = make(map[string]*metrics.Metrics)
+	a.components = make(map[string]models.Kustomization)
+	a.componentMetrics = metrics.NewComponentMetrics(a.promReg)
 
 	// channel/loop for updating data regularly
+	a.appStateUpdate()
 	ch := make(chan bool)
 	a.ch = &ch
 	a.TickerStart(*a.ch)
 
-	a.promReg.MustRegister(
-		&metrics.AWSSubnetCollector{
-			Config:          awsConfig,
-			CustomerName:    cvars["CUSTOMER_NAME"],
-			EnvironmentName: cvars["ENVIRONMENT_NAME"],
-			ProjectName:     cvars["PROJECT_NAME"],
-		},
-		metrics.NewAWSUsageCollector(
-			*a.config, awsConfig,
-			cvars["CUSTOMER_NAME"],
-			cvars["ENVIRONMENT_NAME"],
-			cvars["PROJECT_NAME"],
-		),
+	// kustomization metrics are updated on watch events
+	a.stopCh = make(chan struct{})
+	a.WatcherStart(a.stopCh)
+
+	a.usageCollector = metrics.NewAWSUsageCollector(
+		a.cfg, awsConfig,
+		cache.NewMetrics(a.promReg),
+		cvars["CUSTOMER_NAME"],
+		cvars["ENVIRONMENT_NAME"],
+		cvars["PROJECT_NAME"],
 	)
 
+	// usage survives restarts, the last window is served without rescraping AWS
+	backend, err := persistence.New(c)
+	if err != nil {
+		slog.Error("unable to open usage persistence", "error", err)
+		os.Exit(1)
+	}
+	if backend != nil {
+		err = a.usageCollector.Restore(context.Background(), backend)
+		if err != nil {
+			slog.Error("unable to restore usage snapshots", "backend", c.Persistence.Backend, "error", err)
+		}
+	}
+
+	// the audit log of the write API survives restarts in its own ConfigMap
+	err = a.restoreAudit(context.Background())
+	if err != nil {
+		slog.Error("unable to restore the audit log", "configmap", c.AuditConfigMapName, "error", err)
+	}
+
+	// AWS is scraped in the background, /metrics only reads the last snapshot
+	scrapeMetrics := metrics.NewScrapeMetrics(a.promReg)
+	timeout := func() time.Duration { return a.cfg().AWSScrapeTimeout }
+	subnets := &metrics.AWSSubnetCollector{
+		Config:          awsConfig,
+		AppConfig:       a.cfg,
+		CustomerName:    cvars["CUSTOMER_NAME"],
+		EnvironmentName: cvars["ENVIRONMENT_NAME"],
+		ProjectName:     cvars["PROJECT_NAME"],
+	}
+	// the used IPs behind the exhaustion forecast are kept next to the usage
+	if backend != nil {
+		err = subnets.Restore(context.Background(), backend.Sub("ip-history"))
+		if err != nil {
+			slog.Error("unable to restore the subnet IP history", "backend", c.Persistence.Backend, "error", err)
+		}
+	}
+	a.refreshers = []*metrics.Refresher{
+		{
+			Source:   "aws_subnet",
+			Scraper:  subnets,
+			Interval: func() time.Duration { return a.cfg().AWSSubnetRefreshInterval },
+			Timeout:  timeout,
+			Stats:    scrapeMetrics,
+			Backoff:  awserr.NewBackoff(),
+		},
+		{
+			Source: "aws_compute",
+			Scraper: &metrics.AWSComputeCollector{
+				Config:          awsConfig,
+				AppConfig:       a.cfg,
+				CustomerName:    cvars["CUSTOMER_NAME"],
+				EnvironmentName: cvars["ENVIRONMENT_NAME"],
+				ProjectName:     cvars["PROJECT_NAME"],
+			},
+			Interval: func() time.Duration { return a.cfg().AWSComputeRefreshInterval },
+			Timeout:  timeout,
+			Stats:    scrapeMetrics,
+			Backoff:  awserr.NewBackoff(),
+		},
+		{
+			Source:   "node_capacity",
+			Scraper:  &metrics.NodeCapacityCollector{Clientset: c.K8sClientSet},
+			Interval: func() time.Duration { return a.cfg().NodeCapacityRefreshInterval },
+			Timeout:  timeout,
+			Stats:    scrapeMetrics,
+			Backoff:  awserr.NewBackoff(),
+		},
+		{
+			Source:   "aws_usage",
+			Scraper:  a.usageCollector,
+			Interval: func() time.Duration { return a.cfg().AWSUsageRefreshInterval },
+			Timeout:  timeout,
+			Stats:    scrapeMetrics,
+			Backoff:  a.usageCollector.Backoff,
+		},
+	}
+	for _, refresher := range a.refreshers {
+		a.promReg.MustRegister(refresher)
+		refresher.Start(a.stopCh)
+	}
+
+	// configuration changes are applied live from the ab-infra-manager configmap
+	a.configMetrics = metrics.NewConfigMetrics(a.promReg)
+	a.ConfigWatcherStart(a.stopCh)
+
 	// http gin setup
 	gin.SetMode(gin.ReleaseMode)
 	a.router = gin.Default()
 	a.InitRoutes()
 }
 
+// cfg returns the configuration in effect. It must not be modified, a change is
+// published by storing a modified copy.
+func (a *App) cfg() *models.Cfg {
+	return a.config.Load()
+}
+
 func (a *App) Run() {
 	a.Init()
 	slog.Info("🚀 Running server at 0.0.0.0:8080")
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"accelbyte/ab-infra-manager/pkg/awserr"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// stateMaxAge is how old the last successful state update may get, a few missed
+// ticker cycles, before the pod is reported as not ready.
+const stateMaxAge = 3 * stateUpdateInterval
+
+// readinessChecks reports whether the dependencies of the manager are usable.
+func (a *App) readinessChecks(ctx context.Context) []models.HealthCheckResult {
+	checks := []models.HealthCheckResult{}
+
+	kubernetes := models.HealthCheckResult{Name: "kubernetes", Healthy: true}
+	result := a.cfg().K8sClientSet.Discovery().RESTClient().Get().AbsPath("/readyz").Do(ctx)
+	if err := result.Error(); err != nil {
+		kubernetes.Healthy = false
+		kubernetes.Message = err.Error()
+	}
+	checks = append(checks, kubernetes)
+
+	a.mu.Lock()
+	lastStateUpdate, stateErr := a.lastStateUpdate, a.stateErr
+	a.mu.Unlock()
+
+	clusterVariables := models.HealthCheckResult{Name: "cluster-variables", Healthy: !lastStateUpdate.IsZero()}
+	if stateErr != nil {
+		clusterVariables.Message = stateErr.Error()
+	}
+	if lastStateUpdate.IsZero() && stateErr == nil {
+		clusterVariables.Message = "not read yet"
+	}
+	checks = append(checks, clusterVariables)
+
+	ticker := models.HealthCheckResult{Name: "ticker", Healthy: true}
+	if age := time.Since(lastStateUpdate); !lastStateUpdate.IsZero() && age > stateMaxAge {
+		ticker.Healthy = false
+		ticker.Message = fmt.Sprintf("last successful state update %s ago", age.Round(time.Second))
+	}
+	checks = append(checks, ticker)
+
+	// throttling only delays the metrics, denied access needs attention
+	for _, refresher := range a.refreshers {
+		status := refresher.Backoff.Status()
+		check := models.HealthCheckResult{Name: "aws:" + refresher.Source, Healthy: status.State != awserr.StateAccessDenied}
+		if status.State != awserr.StateOK {
+			check.Message = fmt.Sprintf("%s after %d failures: %s", status.State, status.Failures, status.LastError)
+			if !status.RetryAt.IsZero() {
+				check.Message += fmt.Sprintf(", retrying at %s", status.RetryAt.Format(time.RFC3339))
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"accelbyte/ab-infra-manager/pkg/config"
+	"accelbyte/ab-infra-manager/pkg/k8s"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"log/slog"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// configMapResync re-delivers the ConfigMap periodically, so a reload that failed
+// because of the environment is retried once its resourceVersion changes.
+const configMapResync = 10 * time.Minute
+
+// ConfigWatcherStart applies changes of the ab-infra-manager ConfigMap without
+// restarting the pod.
+func (a *App) ConfigWatcherStart(stop <-chan struct{}) {
+	cfg := a.cfg()
+	informer := k8s.NewConfigMapInformer(cfg.K8sClientSet, cfg.ConfigMapNamespace, cfg.ConfigMapName, configMapResync)
+	_, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: a.configMapUpdated,
+		UpdateFunc: func(_, obj interface{}) {
+			a.configMapUpdated(obj)
+		},
+	})
+	if err != nil {
+		slog.Error("error registering configmap event handler", slog.Any("Error", err))
+		return
+	}
+	go informer.Run(stop)
+}
+
+func (a *App) configMapUpdated(obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		slog.Error("unexpected configmap event", slog.Any("Object", obj))
+		return
+	}
+
+	a.mu.Lock()
+	if cm.ResourceVersion == a.reload.ResourceVersion {
+		a.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	a.reload.ResourceVersion = cm.ResourceVersion
+	a.reload.LastAttempt = now
+
+	// writers are serialized by a.mu, readers only load the pointer
+	current := a.cfg()
+	newCfg, err := config.Reload(*current, cm.Data)
+	if err != nil {
+		a.reload.Success = false
+		a.reload.Error = err.Error()
+		a.mu.Unlock()
+		a.configMetrics.LastReloadSuccessful.Set(0)
+		slog.Error("invalid configuration, keeping the current one",
+			slog.Any("ConfigMap", cm.Name), slog.Any("ResourceVersion", cm.ResourceVersion), slog.Any("Error", err))
+		return
+	}
+
+	usageChanged := newCfg.AWSUsageScrapeInterval != current.AWSUsageScrapeInterval ||
+		newCfg.AWSUsageTimeRange != current.AWSUsageTimeRange ||
+		newCfg.AWSUsagePeriod != current.AWSUsagePeriod ||
+		!slices.Equal(newCfg.AWSUsageStatistics, current.AWSUsageStatistics)
+	a.config.Store(&newCfg)
+	slog.SetLogLoggerLevel(slog.Level(newCfg.LogLevel))
+	a.reload.Success = true
+	a.reload.Error = ""
+	a.reload.LastSuccess = now
+	a.mu.Unlock()
+
+	if usageChanged && a.usageCollector != nil {
+		a.usageCollector.Reset()
+	}
+	// tracked kustomizations, CCU and legacy metrics may have changed
+	a.resyncKustomizations()
+
+	a.configMetrics.LastReloadSuccessful.Set(1)
+	a.configMetrics.LastReloadSuccessTimestamp.Set(float64(now.Unix()))
+	slog.Info("configuration reloaded", slog.Any("ConfigMap", cm.Name), slog.Any("ResourceVersion", cm.ResourceVersion))
+}
+
+// configSnapshot returns a copy of the configuration in effect.
+func (a *App) configSnapshot() models.Cfg {
+	return *a.cfg()
+}
+
+// reloadStatus returns a copy of the last reload attempt.
+func (a *App) reloadStatus() models.ReloadStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.reload
+}
@@ -5,13 +5,32 @@ import (
 )
 
 func (a *App) InitRoutes() {
-	a.router.GET("/healthchecker", (&handler.HealthCheck{Config: a.config}).HealthCheckHandler)
+	health := &handler.HealthCheck{Config: a.configSnapshot, Checks: a.readinessChecks}
+	a.router.GET("/livez", health.LivezHandler)
+	a.router.GET("/readyz", health.ReadyzHandler)
+	a.router.GET("/healthchecker", health.HealthCheckHandler)
 	a.router.GET("/metrics", handler.PrometheusHandler(a.promReg))
 
+	state := &handler.State{Get: a.stateSnapshot, Update: a.updateSetting}
+
 	v1 := a.router.Group("/v1")
 	{
-		v1.GET("/healthchecker", (&handler.HealthCheck{Config: a.config}).HealthCheckHandler)
+		v1.GET("/healthchecker", health.HealthCheckHandler)
 		v1.GET("/metrics", handler.PrometheusHandler(a.promReg))
-		v1.GET("/state", (&handler.State{Data: &a.state}).GetAppStateHandler)
+		v1.GET("/state", state.GetAppStateHandler)
+		v1.GET("/config", (&handler.Config{Config: a.configSnapshot, Reload: a.reloadStatus}).GetConfigHandler)
+		v1.GET("/audit", (&handler.Audit{Entries: a.auditEntries}).GetAuditHandler)
+
+		usage := &handler.Usage{Get: a.usageCollector.Usage, History: a.usageCollector.History}
+		v1.GET("/usage", usage.GetUsageHandler)
+		v1.GET("/usage/:identifier", usage.GetInstanceUsageHandler)
+		v1.GET("/usage/:identifier/history", usage.GetInstanceUsageHistoryHandler)
+		v1.GET("/recommendations", (&handler.Recommendations{Get: a.usageCollector.Recommendations}).GetRecommendationsHandler)
+
+		// API_TOKEN is only read from the environment, a ConfigMap reload keeps it.
+		// CCU and LIVE live in the Flux-managed ConfigMap, a reconcile reverts them.
+		write := v1.Group("", handler.TokenAuth(a.cfg().APIToken))
+		write.PUT("/state/ccu", state.PutCCUHandler)
+		write.PUT("/state/live", state.PutLiveHandler)
 	}
 }
@@ -2,22 +2,100 @@ package controller
 
 import (
 	"accelbyte/ab-infra-manager/pkg/k8s"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 )
 
 func (a *App) appStateUpdate() {
 	project := "justice"
-	clusterVariablesCmData, err := k8s.GetConfigmap(a.config.K8sClientSet, project, "cluster-variables")
+	clusterVariablesCmData, err := k8s.GetConfigmap(a.cfg().K8sClientSet, project, "cluster-variables")
 	if err != nil {
 		slog.Error("error getting cluster-variables configmap")
+		a.mu.Lock()
+		a.stateErr = err
+		a.mu.Unlock()
+		return
 	}
 
 	// from configmap
 	customer := clusterVariablesCmData["CUSTOMER_NAME"]
 	envName := clusterVariablesCmData["ENVIRONMENT_NAME"]
+
+	// the informer handlers and the state API read the state under a.mu
+	a.mu.Lock()
 	a.state.AwsAccountId = clusterVariablesCmData["AWS_ACCOUNT_ID"]
 	a.state.AwsRegion = clusterVariablesCmData["AWS_REGION"]
 	a.state.Environment = fmt.Sprintf("%s-%s-%s", customer, project, envName)
-	a.state.Live = a.config.Live
+	a.state.Live = a.cfg().Live
+	a.stateErr = nil
+	a.lastStateUpdate = time.Now()
+	a.mu.Unlock()
+}
+
+// stateSnapshot returns a copy of the app state.
+func (a *App) stateSnapshot() models.AppState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state
+}
+
+// updateSetting persists a setting in the ab-infra-manager ConfigMap and applies it
+// right away, so the CCU gauges do not wait for the ConfigMap watch. The ConfigMap
+// is deployed by Flux from deployment/configmap.yaml: the next reconcile puts back
+// the value in git, and the watch applies that again. A lasting change needs the
+// manifest updated as well.
+func (a *App) updateSetting(ctx context.Context, actor, setting, value string) (models.AuditEntry, error) {
+	entry := models.AuditEntry{ClaimedActor: actor, Setting: setting, New: value}
+
+	// the patch is built under a.mu and sent without it, the informer handlers
+	// and the state API do not wait for the Kubernetes API
+	a.mu.Lock()
+	cfg := a.cfg()
+	var apply func(c *models.Cfg)
+	switch setting {
+	case "CCU":
+		ccu, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			a.mu.Unlock()
+			return entry, fmt.Errorf("invalid CCU %q", value)
+		}
+		entry.Old = strconv.FormatInt(cfg.CCU, 10)
+		apply = func(c *models.Cfg) { c.CCU = ccu }
+	case "LIVE":
+		live, err := strconv.ParseBool(value)
+		if err != nil {
+			a.mu.Unlock()
+			return entry, fmt.Errorf("invalid LIVE %q", value)
+		}
+		entry.Old = strconv.FormatBool(cfg.Live)
+		apply = func(c *models.Cfg) { c.Live = live }
+	default:
+		a.mu.Unlock()
+		return entry, fmt.Errorf("unknown setting %s", setting)
+	}
+	a.mu.Unlock()
+
+	err := k8s.PatchConfigmap(ctx, cfg.K8sClientSet, cfg.ConfigMapNamespace, cfg.ConfigMapName, map[string]string{setting: value})
+	if err != nil {
+		return entry, fmt.Errorf("error persisting %s: %w", setting, err)
+	}
+
+	// applied to the configuration in effect now, a reload may have happened meanwhile
+	a.mu.Lock()
+	next := *a.cfg()
+	apply(&next)
+	a.config.Store(&next)
+	a.state.Live = next.Live
+	entry.Time = time.Now()
+	a.appendAudit(entry)
+	a.mu.Unlock()
+
+	slog.Info("audit", slog.Any("ClaimedActor", entry.ClaimedActor), slog.Any("Setting", entry.Setting), slog.Any("Old", entry.Old), slog.Any("New", entry.New))
+	a.persistAudit(ctx)
+	a.resyncKustomizations()
+	return entry, nil
 }
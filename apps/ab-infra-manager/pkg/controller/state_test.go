@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"accelbyte/ab-infra-manager/pkg/metrics"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestApp returns an app backed by a fake Kubernetes API holding objects and
+// the audit ConfigMap.
+func newTestApp(objects ...runtime.Object) *App {
+	reg := prometheus.NewRegistry()
+	audit := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "ab-infra-manager-audit", Namespace: "justice"}}
+	clientset := fake.NewSimpleClientset(append(objects, audit)...)
+	a := &App{
+		metrics:          make(map[string]*metrics.Metrics),
+		promReg:          reg,
+		components:       make(map[string]models.Kustomization),
+		componentMetrics: metrics.NewComponentMetrics(reg),
+		configMetrics:    metrics.NewConfigMetrics(reg),
+	}
+	a.config.Store(&models.Cfg{
+		K8sClientSet:       clientset,
+		KsTrackKey:         "ab-infra-manager/track",
+		ConfigMapNamespace: "justice",
+		ConfigMapName:      "ab-infra-manager",
+		AuditConfigMapName: "ab-infra-manager-audit",
+		LegacyMetrics:      true,
+	})
+	return a
+}
+
+func clusterVariables() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-variables", Namespace: "justice"},
+		Data: map[string]string{
+			"CUSTOMER_NAME":    "accelbyte",
+			"ENVIRONMENT_NAME": "dev",
+			"AWS_ACCOUNT_ID":   "123456789012",
+			"AWS_REGION":       "us-west-2",
+		},
+	}
+}
+
+func trackedKustomization(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kustomize.toolkit.fluxcd.io/v1beta2",
+		"kind":       "Kustomization",
+		"metadata": map[string]interface{}{
+			"name":   name,
+			"labels": map[string]interface{}{"ab-infra-manager/track": "true"},
+		},
+		"spec": map[string]interface{}{"path": "./manifests/" + name + "/v1.2.3"},
+	}}
+}
+
+// TestStateConcurrentAccess runs the ticker, the informer handlers and the state
+// API together, run it with -race.
+func TestStateConcurrentAccess(t *testing.T) {
+	a := newTestApp(clusterVariables())
+	ks := trackedKustomization("justice-lobby")
+
+	var wg sync.WaitGroup
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 500 {
+				f()
+			}
+		}()
+	}
+	run(a.appStateUpdate)
+	run(func() { a.kustomizationUpdated(ks) })
+	run(func() { a.stateSnapshot() })
+	wg.Wait()
+
+	state := a.stateSnapshot()
+	if state.Environment != "accelbyte-justice-dev" || state.AwsRegion != "us-west-2" {
+		t.Errorf("state = %+v", state)
+	}
+	if _, ok := a.components["justice-lobby"]; !ok {
+		t.Error("justice-lobby is not tracked")
+	}
+}
+
+// TestConfigReloadConcurrentAccess reloads the configuration while the refreshers
+// and the informer handlers read it, run it with -race.
+func TestConfigReloadConcurrentAccess(t *testing.T) {
+	a := newTestApp(clusterVariables())
+	ks := trackedKustomization("justice-lobby")
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := range 500 {
+			a.configMapUpdated(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "ab-infra-manager", ResourceVersion: strconv.Itoa(i + 1)},
+				Data:       map[string]string{"CCU": strconv.Itoa(i), "LIVE": "false"},
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 500 {
+			a.kustomizationUpdated(ks)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 500 {
+			cfg := a.cfg()
+			_ = cfg.CCU + int64(cfg.AWSScrapeTimeout)
+			_ = a.configSnapshot()
+		}
+	}()
+	wg.Wait()
+
+	if got := a.cfg().CCU; got != 499 {
+		t.Errorf("CCU = %d, want the last reloaded value 499", got)
+	}
+	if status := a.reloadStatus(); !status.Success || status.ResourceVersion != "500" {
+		t.Errorf("reload status = %+v", status)
+	}
+}
+
+func TestUpdateSetting(t *testing.T) {
+	settings := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ab-infra-manager", Namespace: "justice"},
+		Data:       map[string]string{"CCU": "100", "LIVE": "false"},
+	}
+	a := newTestApp(clusterVariables(), settings)
+	ctx := context.Background()
+
+	entry, err := a.updateSetting(ctx, "jenkins", "CCU", "500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ClaimedActor != "jenkins" || entry.Old != "0" || entry.New != "500" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if _, err := a.updateSetting(ctx, "jenkins", "LIVE", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.updateSetting(ctx, "jenkins", "CCU", "many"); err == nil {
+		t.Error("an invalid CCU was accepted")
+	}
+
+	if cfg := a.cfg(); cfg.CCU != 500 || !cfg.Live {
+		t.Errorf("config CCU = %d, Live = %v", cfg.CCU, cfg.Live)
+	}
+	if !a.stateSnapshot().Live {
+		t.Error("state is not live")
+	}
+	cm, err := a.cfg().K8sClientSet.CoreV1().ConfigMaps("justice").Get(ctx, "ab-infra-manager", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["CCU"] != "500" || cm.Data["LIVE"] != "true" {
+		t.Errorf("configmap data = %v", cm.Data)
+	}
+
+	// the audit log is plain JSON, and a new pod reads it back
+	audit, err := a.cfg().K8sClientSet.CoreV1().ConfigMaps("justice").Get(ctx, "ab-infra-manager-audit", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var persisted []models.AuditEntry
+	if err := json.Unmarshal([]byte(audit.Data["audit.json"]), &persisted); err != nil || len(persisted) != 2 {
+		t.Errorf("audit configmap data = %v, err = %v", audit.Data, err)
+	}
+	restarted := newTestApp()
+	restarted.config.Store(a.cfg())
+	if err := restarted.restoreAudit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var settingsChanged []string
+	for _, entry := range restarted.auditEntries() {
+		settingsChanged = append(settingsChanged, entry.Setting+"="+entry.New)
+	}
+	if want := []string{"CCU=500", "LIVE=true"}; !slices.Equal(settingsChanged, want) {
+		t.Errorf("restored audit log = %v, want %v", settingsChanged, want)
+	}
+}
@@ -1,17 +1,14 @@
 package controller
 
 import (
-	"accelbyte/ab-infra-manager/pkg/k8s"
-	"accelbyte/ab-infra-manager/pkg/utils"
-	"fmt"
-	"log/slog"
 	"time"
-
-	"github.com/prometheus/client_golang/prometheus"
 )
 
+// stateUpdateInterval is how often the app state is read from cluster-variables.
+const stateUpdateInterval = 120 * time.Second
+
 func (a *App) TickerStart(done <-chan bool) {
-	ticker := time.NewTicker(120 * time.Second)
+	ticker := time.NewTicker(stateUpdateInterval)
 	go func() {
 		for ; true; <-ticker.C {
 			select {
@@ -20,24 +17,6 @@ func (a *App) TickerStart(done <-chan bool) {
 				return
 			default:
 				a.appStateUpdate()
-				for ks, metric := range a.metrics {
-					ksPath, _ := k8s.GetKustomizationPath(a.config.K8sDynamicClientSet, a.config.KsNamespace, ks)
-					version, err := utils.ParseVersion(ksPath)
-					if err != nil {
-						slog.Error("error parsing version from path", slog.Any("Path", ksPath))
-					}
-					subversion := utils.ParseSubVersion(ksPath)
-					metric.CCU.Reset()
-					metric.CCU.With(prometheus.Labels{
-						"environment":    a.state.Environment,
-						"aws_account_id": a.state.AwsAccountId,
-						"aws_region":     a.state.AwsRegion,
-						"resource":       ks,
-						"version":        version,
-						"patch":          subversion,
-						"live":           fmt.Sprint(a.state.Live),
-					}).Set(float64(a.config.CCU))
-				}
 			}
 		}
 	}()
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"accelbyte/ab-infra-manager/pkg/k8s"
+	"accelbyte/ab-infra-manager/pkg/metrics"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/utils"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// kustomizationResync re-delivers every Kustomization periodically, so labels that
+// come from the app state (environment, live) are refreshed without any change.
+const kustomizationResync = 120 * time.Second
+
+func (a *App) WatcherStart(stop <-chan struct{}) {
+	cfg := a.cfg()
+	informer := k8s.NewKustomizationInformer(cfg.K8sDynamicClientSet, cfg.KsNamespace, kustomizationResync)
+	_, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: a.kustomizationUpdated,
+		UpdateFunc: func(_, obj interface{}) {
+			a.kustomizationUpdated(obj)
+		},
+		DeleteFunc: a.kustomizationDeleted,
+	})
+	if err != nil {
+		slog.Error("error registering kustomization event handler", slog.Any("Error", err))
+		return
+	}
+	a.ksInformer = informer
+	go informer.Run(stop)
+}
+
+func (a *App) WatcherStop(stop chan<- struct{}) {
+	close(stop)
+}
+
+func (a *App) kustomizationUpdated(obj interface{}) {
+	ks, err := k8s.ToKustomization(obj)
+	if err != nil {
+		slog.Error("error reading kustomization event", slog.Any("Error", err))
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.isTracked(ks) {
+		// the label or annotation may have been removed
+		a.untrackKustomization(ks.Name)
+		return
+	}
+
+	if _, ok := a.components[ks.Name]; !ok {
+		slog.Info("tracking kustomization", slog.Any("Kustomization", ks.Name))
+	}
+	a.components[ks.Name] = ks
+	slog.Debug("kustomization updated", slog.Any("Kustomization", ks))
+	a.setComponentMetrics(ks)
+}
+
+func (a *App) kustomizationDeleted(obj interface{}) {
+	ks, err := k8s.ToKustomization(obj)
+	if err != nil {
+		slog.Error("error reading kustomization event", slog.Any("Error", err))
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.untrackKustomization(ks.Name)
+}
+
+// resyncKustomizations re-evaluates every known Kustomization, e.g. after the
+// configuration changed which ones are tracked or how they are exposed.
+func (a *App) resyncKustomizations() {
+	if a.ksInformer == nil {
+		return
+	}
+	for _, obj := range a.ksInformer.GetStore().List() {
+		a.kustomizationUpdated(obj)
+	}
+}
+
+// isTracked reports whether a Kustomization opted in with the track label or
+// annotation, or is listed in KUSTOMIZATION_RESOURCES.
+func (a *App) isTracked(ks models.Kustomization) bool {
+	cfg := a.cfg()
+	if ks.Labels[cfg.KsTrackKey] == "true" || ks.Annotations[cfg.KsTrackKey] == "true" {
+		return true
+	}
+	return slices.Contains(cfg.KsResources, ks.Name)
+}
+
+func (a *App) untrackKustomization(name string) {
+	if _, ok := a.components[name]; !ok {
+		return
+	}
+	slog.Info("untracking kustomization", slog.Any("Kustomization", name))
+	delete(a.components, name)
+	a.componentMetrics.Delete(name)
+	if metric, ok := a.metrics[name]; ok {
+		metrics.Unregister(a.promReg, metric)
+		delete(a.metrics, name)
+	}
+}
+
+// setComponentMetrics replaces the series of a Kustomization, since its version
+// and revision are labels.
+func (a *App) setComponentMetrics(ks models.Kustomization) {
+	version, err := utils.ParseVersion(ks.Path)
+	if err != nil {
+		slog.Error("error parsing version from path", slog.Any("Path", ks.Path))
+	}
+	patch := utils.ParseSubVersion(ks.Path)
+	cfg := a.cfg()
+	labels := prometheus.Labels{
+		"environment":    a.state.Environment,
+		"aws_account_id": a.state.AwsAccountId,
+		"aws_region":     a.state.AwsRegion,
+		"resource":       ks.Name,
+	}
+
+	a.componentMetrics.Delete(ks.Name)
+	info := maps.Clone(labels)
+	info["version"] = version
+	info["patch"] = patch
+	info["revision"] = ks.Revision
+	info["live"] = fmt.Sprint(a.state.Live)
+	a.componentMetrics.Info.With(info).Set(float64(cfg.CCU))
+
+	ready := 0.0
+	if ks.Ready == "True" {
+		ready = 1
+	}
+	a.componentMetrics.Ready.With(labels).Set(ready)
+	if !ks.LastReconcileTime.IsZero() {
+		a.componentMetrics.LastReconcile.With(labels).Set(float64(ks.LastReconcileTime.Unix()))
+	}
+
+	metric, ok := a.metrics[ks.Name]
+	if !cfg.LegacyMetrics {
+		if ok {
+			metrics.Unregister(a.promReg, metric)
+			delete(a.metrics, ks.Name)
+		}
+		return
+	}
+	if !ok {
+		metric, err = metrics.Register(a.promReg, ks.Name)
+		if err != nil {
+			slog.Error("error registering legacy kustomization metrics", slog.Any("Kustomization", ks.Name), slog.Any("Error", err))
+			return
+		}
+		a.metrics[ks.Name] = metric
+	}
+	metric.CCU.Reset()
+	legacy := maps.Clone(labels)
+	legacy["version"] = version
+	legacy["patch"] = patch
+	legacy["live"] = info["live"]
+	metric.CCU.With(legacy).Set(float64(cfg.CCU))
+}
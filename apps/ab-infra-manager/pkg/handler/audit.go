@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Audit struct {
+	Entries func() []models.AuditEntry
+}
+
+func (a *Audit) GetAuditHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, a.Entries())
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActorHeader is how the caller of the write API names itself, e.g. the Jenkins
+// job. The audit log keeps it as claimed, the shared token does not tell callers apart.
+const ActorHeader = "X-Actor"
+
+// TokenAuth only lets requests through with "Authorization: Bearer <token>" and an
+// X-Actor header. With no token configured every request is rejected.
+func TokenAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "write API is disabled, API_TOKEN is not set"})
+			return
+		}
+		bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing bearer token"})
+			return
+		}
+		if strings.TrimSpace(c.GetHeader(ActorHeader)) == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": ActorHeader + " header is required"})
+			return
+		}
+		c.Next()
+	}
+}
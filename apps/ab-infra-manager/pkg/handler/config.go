@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Config struct {
+	Config func() models.Cfg
+	Reload func() models.ReloadStatus
+}
+
+// GetConfigHandler returns the configuration in effect and the outcome of the
+// last reload from the ab-infra-manager ConfigMap.
+func (cfg *Config) GetConfigHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"config": cfg.Config(),
+		"reload": cfg.Reload(),
+	})
+}
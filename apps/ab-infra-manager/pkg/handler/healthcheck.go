@@ -2,21 +2,48 @@ package handler
 
 import (
 	"accelbyte/ab-infra-manager/pkg/models"
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// readinessTimeout bounds the checks that call out to the Kubernetes API.
+const readinessTimeout = 5 * time.Second
+
 type HealthCheck struct {
-	Status string
-	Config *models.Cfg
+	// Config returns the configuration in effect, clientsets and tokens are not serialised.
+	Config func() models.Cfg
+	Checks func(ctx context.Context) []models.HealthCheckResult
 }
 
-func (hc *HealthCheck) HealthCheckHandler(c *gin.Context) {
-	// TODO: Healthcheck algorithm
-	hc.Status = "healthy"
-	c.JSON(http.StatusOK, gin.H{
-		"status": hc.Status,
-		"config": hc.Config,
+// LivezHandler only reports that the process is serving requests.
+func (hc *HealthCheck) LivezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadyzHandler runs the readiness checks and answers 503 when any of them fails.
+func (hc *HealthCheck) ReadyzHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	status, code := "ready", http.StatusOK
+	checks := hc.Checks(ctx)
+	for _, check := range checks {
+		if !check.Healthy {
+			status, code = "not ready", http.StatusServiceUnavailable
+			break
+		}
+	}
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+		"config": hc.Config(),
 	})
 }
+
+// HealthCheckHandler is kept for existing probes and dashboards, it is the readiness check.
+func (hc *HealthCheck) HealthCheckHandler(c *gin.Context) {
+	hc.ReadyzHandler(c)
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"accelbyte/ab-infra-manager/pkg/recommend"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Recommendations struct {
+	Get func(ctx context.Context) ([]recommend.Recommendation, error)
+}
+
+// GetRecommendationsHandler lists the rightsizing recommendations, optionally
+// filtered with ?action=downsize|keep|upsize.
+func (r *Recommendations) GetRecommendationsHandler(c *gin.Context) {
+	recommendations, err := r.Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	action := c.Query("action")
+	filtered := []recommend.Recommendation{}
+	for _, recommendation := range recommendations {
+		if action == "" || recommendation.Action == action {
+			filtered = append(filtered, recommendation)
+		}
+	}
+	c.JSON(http.StatusOK, filtered)
+}
@@ -2,15 +2,63 @@ package handler
 
 import (
 	"accelbyte/ab-infra-manager/pkg/models"
+	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type State struct {
-	Data *models.AppState
+	// Get returns a copy of the app state.
+	Get func() models.AppState
+	// Update persists a setting and applies it, returning the audit entry. The
+	// setting is patched into the Flux-managed ab-infra-manager ConfigMap, the next
+	// Flux reconcile reverts it unless the manifest in git is changed too.
+	Update func(ctx context.Context, actor, setting, value string) (models.AuditEntry, error)
 }
 
 func (s *State) GetAppStateHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, s.Data)
+	c.JSON(http.StatusOK, s.Get())
+}
+
+type ccuRequest struct {
+	CCU *int64 `json:"ccu" binding:"required"`
+}
+
+type liveRequest struct {
+	Live *bool `json:"live" binding:"required"`
+}
+
+// PutCCUHandler sets the CCU tier of the environment, e.g. {"ccu": 500}.
+func (s *State) PutCCUHandler(c *gin.Context) {
+	var req ccuRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *req.CCU < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ccu must not be negative"})
+		return
+	}
+	s.update(c, "CCU", strconv.FormatInt(*req.CCU, 10))
+}
+
+// PutLiveHandler sets whether the environment is live, e.g. {"live": true}.
+func (s *State) PutLiveHandler(c *gin.Context) {
+	var req liveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.update(c, "LIVE", strconv.FormatBool(*req.Live))
+}
+
+func (s *State) update(c *gin.Context, setting, value string) {
+	entry, err := s.Update(c.Request.Context(), c.GetHeader(ActorHeader), setting, value)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
 }
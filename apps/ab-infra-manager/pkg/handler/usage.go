@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"accelbyte/ab-infra-manager/pkg/usagestore"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Usage struct {
+	Get     func(ctx context.Context, refresh bool) ([]usagestore.Usage, error)
+	History func() []usagestore.Snapshot
+}
+
+// UsageWindow is the usage of an identifier in one retained window.
+type UsageWindow struct {
+	Window time.Time          `json:"window"`
+	Usage  []usagestore.Usage `json:"usage"`
+}
+
+// GetUsageHandler lists the usage of every instance, optionally filtered with
+// ?engine= and ?level=. ?refresh=true rescrapes AWS instead of using the cache.
+func (u *Usage) GetUsageHandler(c *gin.Context) {
+	usages, ok := u.get(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, usages)
+}
+
+// GetInstanceUsageHandler returns the usage of one identifier. A Kafka cluster
+// is returned with its brokers.
+func (u *Usage) GetInstanceUsageHandler(c *gin.Context) {
+	usages, ok := u.get(c)
+	if !ok {
+		return
+	}
+	identifier := c.Param("identifier")
+	matched := []usagestore.Usage{}
+	for _, usage := range usages {
+		if usage.Identifier == identifier || usage.Key == identifier {
+			matched = append(matched, usage)
+		}
+	}
+	if len(matched) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no usage for " + identifier})
+		return
+	}
+	c.JSON(http.StatusOK, matched)
+}
+
+// GetInstanceUsageHistoryHandler returns the usage of one identifier in every
+// retained window, oldest first, to follow its trend.
+func (u *Usage) GetInstanceUsageHistoryHandler(c *gin.Context) {
+	identifier := c.Param("identifier")
+	windows := []UsageWindow{}
+	for _, snapshot := range u.History() {
+		window := UsageWindow{Window: snapshot.Window}
+		for _, usage := range snapshot.Usage {
+			if usage.Identifier == identifier || usage.Key == identifier {
+				window.Usage = append(window.Usage, usage)
+			}
+		}
+		if len(window.Usage) > 0 {
+			windows = append(windows, window)
+		}
+	}
+	if len(windows) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no usage history for " + identifier})
+		return
+	}
+	c.JSON(http.StatusOK, windows)
+}
+
+func (u *Usage) get(c *gin.Context) ([]usagestore.Usage, bool) {
+	refresh := false
+	if value := c.Query("refresh"); value != "" {
+		var err error
+		refresh, err = strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refresh must be true or false"})
+			return nil, false
+		}
+	}
+
+	usages, err := u.Get(c.Request.Context(), refresh)
+	if err != nil && len(usages) == 0 {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// instances that failed carry their own error, the others are still returned
+	engine, level := c.Query("engine"), c.Query("level")
+	filtered := []usagestore.Usage{}
+	for _, usage := range usages {
+		if engine != "" && !strings.EqualFold(usage.Engine, engine) {
+			continue
+		}
+		if level != "" && !strings.EqualFold(usage.Level, level) {
+			continue
+		}
+		filtered = append(filtered, usage)
+	}
+	return filtered, true
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+// Package instanceclass parses the instance classes of the AWS managed services,
+// e.g. db.r6g.large, cache.t4g.micro or r6g.large.search, and knows the vCPUs and
+// memory of their sizes.
+package instanceclass
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// standardVCPUs are the vCPUs of the sizes of non-burstable families. Memory
+// scales with them, see memoryPerVCPU.
+var standardVCPUs = map[string]float64{
+	"medium": 1, "large": 2, "xlarge": 4, "2xlarge": 8, "3xlarge": 12, "4xlarge": 16,
+	"6xlarge": 24, "8xlarge": 32, "9xlarge": 36, "10xlarge": 40, "12xlarge": 48,
+	"16xlarge": 64, "18xlarge": 72, "24xlarge": 96, "32xlarge": 128, "48xlarge": 192,
+}
+
+// burstableMemory is the memory in GiB of the sizes of burstable (t*) families.
+// Their vCPUs run on credits, the memory is what differs between sizes.
+var burstableMemory = map[string]float64{
+	"nano": 0.5, "micro": 1, "small": 2, "medium": 4, "large": 8, "xlarge": 16, "2xlarge": 32,
+}
+
+// memoryPerVCPU is the memory in GiB per vCPU of the non-burstable families,
+// matched by prefix, e.g. 8 for r6g and x1e before x.
+var memoryPerVCPU = []struct {
+	prefix string
+	gib    float64
+}{
+	{"x1e", 32}, {"x2ie", 32}, {"x", 16}, {"r", 8}, {"z", 8}, {"or", 8}, {"m", 4}, {"c", 2},
+}
+
+// Sizes recommendations move between, smallest first. Each step roughly doubles
+// the vCPUs or memory. The other sizes are recognized, a class in one of them
+// moves to the nearest size of its ladder. Not every family offers every size.
+var (
+	burstableLadder = []string{"nano", "micro", "small", "medium", "large", "xlarge", "2xlarge"}
+	standardLadder  = []string{"large", "xlarge", "2xlarge", "4xlarge", "8xlarge", "12xlarge", "16xlarge", "24xlarge", "32xlarge", "48xlarge"}
+)
+
+// Class is an instance class split into its family and size.
+type Class struct {
+	Prefix, Family, Size, Suffix string
+}
+
+// Parse splits an instance class like db.r6g.large, cache.t4g.medium,
+// kafka.m5.large or, for OpenSearch, r6g.large.search. Classes with an unknown
+// size, e.g. db.serverless or db.r6g.metal, are not parsed.
+func Parse(class string) (Class, bool) {
+	parts := strings.Split(class, ".")
+	if len(parts) != 3 {
+		return Class{}, false
+	}
+	c := Class{Prefix: parts[0], Family: parts[1], Size: parts[2]}
+	if parts[2] == "search" {
+		c = Class{Family: parts[0], Size: parts[1], Suffix: parts[2]}
+	}
+	if c.Capacity() == 0 {
+		return Class{}, false
+	}
+	return c, true
+}
+
+func (c Class) String() string {
+	if c.Suffix != "" {
+		return fmt.Sprintf("%s.%s.%s", c.Family, c.Size, c.Suffix)
+	}
+	return fmt.Sprintf("%s.%s.%s", c.Prefix, c.Family, c.Size)
+}
+
+// Resize returns the class of the same family in another size.
+func (c Class) Resize(size string) Class {
+	c.Size = size
+	return c
+}
+
+// Burstable reports whether the family runs on CPU credits, e.g. t4g.
+func (c Class) Burstable() bool {
+	return strings.HasPrefix(c.Family, "t")
+}
+
+// Capacity is the relative size of the class: its vCPUs, or its memory in GiB for
+// burstable families. 0 when the size is unknown.
+func (c Class) Capacity() float64 {
+	if c.Burstable() {
+		return burstableMemory[c.Size]
+	}
+	return standardVCPUs[c.Size]
+}
+
+// MemoryGiB is the memory of the class, 0 when unknown. Services keep some of it
+// for the operating system.
+func (c Class) MemoryGiB() float64 {
+	if c.Burstable() {
+		return burstableMemory[c.Size]
+	}
+	for _, family := range memoryPerVCPU {
+		if strings.HasPrefix(c.Family, family.prefix) {
+			return family.gib * standardVCPUs[c.Size]
+		}
+	}
+	return 0
+}
+
+func (c Class) ladder() []string {
+	if c.Burstable() {
+		return burstableLadder
+	}
+	return standardLadder
+}
+
+// Larger returns the next larger class of the ladder, false for the largest.
+func (c Class) Larger() (Class, bool) {
+	capacity := c.Capacity()
+	for _, size := range c.ladder() {
+		if next := c.Resize(size); next.Capacity() > capacity {
+			return next, true
+		}
+	}
+	return Class{}, false
+}
+
+// Smaller returns the next smaller class of the ladder, false for the smallest.
+func (c Class) Smaller() (Class, bool) {
+	capacity := c.Capacity()
+	for _, size := range slices.Backward(c.ladder()) {
+		if next := c.Resize(size); next.Capacity() < capacity {
+			return next, true
+		}
+	}
+	return Class{}, false
+}
@@ -0,0 +1,98 @@
+package instanceclass
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		class  string
+		ok     bool
+		family string
+		size   string
+	}{
+		{"db.r6g.large", true, "r6g", "large"},
+		{"cache.t4g.micro", true, "t4g", "micro"},
+		{"db.t4g.nano", true, "t4g", "nano"},
+		{"db.m5.3xlarge", true, "m5", "3xlarge"},
+		{"db.r7i.48xlarge", true, "r7i", "48xlarge"},
+		{"r6g.large.search", true, "r6g", "large"},
+		{"t3.small.search", true, "t3", "small"},
+		{"db.serverless", false, "", ""},
+		{"db.r6g.metal", false, "", ""},
+		{"db.t4g.4xlarge", false, "", ""},
+		{"r6g.large.elasticsearch", false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			class, ok := Parse(tt.class)
+			if ok != tt.ok {
+				t.Fatalf("Parse(%q) ok = %v, want %v", tt.class, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if class.Family != tt.family || class.Size != tt.size {
+				t.Errorf("Parse(%q) = %+v", tt.class, class)
+			}
+			if got := class.String(); got != tt.class {
+				t.Errorf("String() = %q, want %q", got, tt.class)
+			}
+		})
+	}
+}
+
+func TestMemoryGiB(t *testing.T) {
+	tests := []struct {
+		class string
+		want  float64
+	}{
+		{"db.r6g.xlarge", 32},
+		{"db.m6g.large", 8},
+		{"db.x2g.large", 32},
+		{"db.x2iedn.xlarge", 128},
+		{"db.t4g.micro", 1},
+		{"db.t3.nano", 0.5},
+		{"r6g.large.search", 16},
+		{"cache.c7gn.large", 4},
+		{"i3.large.search", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			class, ok := Parse(tt.class)
+			if !ok {
+				t.Fatalf("Parse(%q) failed", tt.class)
+			}
+			if got := class.MemoryGiB(); got != tt.want {
+				t.Errorf("MemoryGiB() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLadder(t *testing.T) {
+	tests := []struct {
+		class, smaller, larger string
+	}{
+		{"db.r6g.xlarge", "db.r6g.large", "db.r6g.2xlarge"},
+		{"db.r6g.large", "", "db.r6g.xlarge"},
+		{"db.m6g.medium", "", "db.m6g.large"},
+		{"db.m5.3xlarge", "db.m5.2xlarge", "db.m5.4xlarge"},
+		{"db.r5.6xlarge", "db.r5.4xlarge", "db.r5.8xlarge"},
+		{"db.r6i.32xlarge", "db.r6i.24xlarge", "db.r6i.48xlarge"},
+		{"db.r7i.48xlarge", "db.r7i.32xlarge", ""},
+		{"cache.t4g.nano", "", "cache.t4g.micro"},
+		{"cache.t4g.2xlarge", "cache.t4g.xlarge", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			class, _ := Parse(tt.class)
+			smaller, ok := class.Smaller()
+			if got := smaller.String(); ok != (tt.smaller != "") || ok && got != tt.smaller {
+				t.Errorf("Smaller() = %q, %v, want %q", got, ok, tt.smaller)
+			}
+			larger, ok := class.Larger()
+			if got := larger.String(); ok != (tt.larger != "") || ok && got != tt.larger {
+				t.Errorf("Larger() = %q, %v, want %q", got, ok, tt.larger)
+			}
+		})
+	}
+}
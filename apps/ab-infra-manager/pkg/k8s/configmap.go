@@ -2,13 +2,19 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
 )
 
-func GetConfigmap(clientset *kubernetes.Clientset, namespace string, configMapName string) (map[string]string, error) {
+func GetConfigmap(clientset kubernetes.Interface, namespace string, configMapName string) (map[string]string, error) {
 	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), configMapName, metav1.GetOptions{})
 	if err != nil {
 		slog.Error("failed to get configmap in namespace", slog.Any("Error", err))
@@ -17,3 +23,25 @@ func GetConfigmap(clientset *kubernetes.Clientset, namespace string, configMapNa
 
 	return configMap.Data, nil
 }
+
+// PatchConfigmap sets the given keys of a ConfigMap, leaving the others untouched.
+func PatchConfigmap(ctx context.Context, clientset kubernetes.Interface, namespace string, configMapName string, data map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Patch(ctx, configMapName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// NewConfigMapInformer returns an informer for a single ConfigMap.
+// The ConfigMap is re-delivered as an update after each resync period.
+func NewConfigMapInformer(clientset kubernetes.Interface, namespace string, configMapName string, resync time.Duration) toolscache.SharedIndexInformer {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, resync,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", configMapName).String()
+		}),
+	)
+	return factory.Core().V1().ConfigMaps().Informer()
+}
@@ -1,32 +1,68 @@
 package k8s
 
 import (
-	"context"
-	"log/slog"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"fmt"
+	"time"
 
 	kustomizev1beta2 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	toolscache "k8s.io/client-go/tools/cache"
 )
 
-func GetKustomizationPath(clientset *dynamic.DynamicClient, namespace string, ks string) (string, error) {
-	kustomizeUnstructured, err := clientset.
-		Resource(kustomizev1beta2.GroupVersion.WithResource("kustomizations")).
-		Namespace(namespace).
-		Get(context.Background(), ks, v1.GetOptions{})
-	if err != nil {
-		slog.Error("error getting kustomization", slog.Any("Kustomization", ks), slog.Any("Error", err))
-		return "", err
+var KustomizationGVR = kustomizev1beta2.GroupVersion.WithResource("kustomizations")
+
+// NewKustomizationInformer returns an informer for the Kustomizations in namespace.
+// Every object is re-delivered as an update after each resync period.
+func NewKustomizationInformer(clientset dynamic.Interface, namespace string, resync time.Duration) toolscache.SharedIndexInformer {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(clientset, resync, namespace, nil)
+	return factory.ForResource(KustomizationGVR).Informer()
+}
+
+// ToKustomization converts an informer object, including delete tombstones,
+// into the observed state of the Kustomization.
+func ToKustomization(obj interface{}) (models.Kustomization, error) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	kustomizeUnstructured, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return models.Kustomization{}, fmt.Errorf("unexpected kustomization object type %T", obj)
 	}
 
 	kustomization := kustomizev1beta2.Kustomization{}
-	err = runtime.DefaultUnstructuredConverter.
+	err := runtime.DefaultUnstructuredConverter.
 		FromUnstructured(kustomizeUnstructured.UnstructuredContent(), &kustomization)
 	if err != nil {
-		slog.Error("error converting kustomization schema to structured data", slog.Any("Kustomization", ks), slog.Any("Error", err))
-		return "", err
+		return models.Kustomization{}, fmt.Errorf("error converting kustomization %s to structured data: %w", kustomizeUnstructured.GetName(), err)
 	}
 
-	return kustomization.Spec.Path, nil
+	ready := v1.ConditionUnknown
+	var lastReconcileTime time.Time
+	if condition := apimeta.FindStatusCondition(kustomization.Status.Conditions, "Ready"); condition != nil {
+		ready = condition.Status
+		lastReconcileTime = condition.LastTransitionTime.Time
+	}
+	// the handled request is the requestedAt annotation value, which flux sets to
+	// the request time
+	if requested, err := time.Parse(time.RFC3339Nano, kustomization.Status.LastHandledReconcileAt); err == nil && requested.After(lastReconcileTime) {
+		lastReconcileTime = requested
+	}
+
+	return models.Kustomization{
+		Name:     kustomization.Name,
+		Path:     kustomization.Spec.Path,
+		Revision: kustomization.Status.LastAppliedRevision,
+		Ready:    string(ready),
+
+		LastReconcileTime: lastReconcileTime,
+
+		Labels:      kustomization.Labels,
+		Annotations: kustomization.Annotations,
+	}, nil
 }
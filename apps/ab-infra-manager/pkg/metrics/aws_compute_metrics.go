@@ -0,0 +1,195 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package metrics
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/ownership"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Tags of the EC2 instances of a Karpenter NodePool or an EKS managed node group.
+var nodePoolTags = []string{"karpenter.sh/nodepool", "eks:nodegroup-name"}
+
+// AWSComputeCollector reports the EC2 instance types of the environment and the
+// vCPU quotas of the account.
+type AWSComputeCollector struct {
+	Config aws.Config
+	// AppConfig returns the configuration in effect, it is read once per scrape.
+	AppConfig       func() *models.Cfg
+	CustomerName    string
+	EnvironmentName string
+	ProjectName     string
+}
+
+// instanceGroup is the instances of a type in a node pool.
+type instanceGroup struct {
+	instanceType string
+	nodePool     string
+	capacityType string
+}
+
+// Scrape reads the EC2 instances and the vCPU quotas, it runs in the background of a Refresher.
+func (c *AWSComputeCollector) Scrape(ctx context.Context) ([]prometheus.Metric, error) {
+	cfg := c.AppConfig()
+	rules, err := ownership.NewRules(cfg.Ownership, map[string]string{
+		"customer_name":    c.CustomerName,
+		"project":          c.ProjectName,
+		"environment_name": c.EnvironmentName,
+	}, fmt.Sprintf("%s-%s-%s", c.CustomerName, c.ProjectName, c.EnvironmentName))
+	if err != nil {
+		return nil, err
+	}
+
+	// every instance of the account counts towards its quotas
+	var discovered, matched int
+	instances := make(map[instanceGroup]int)
+	vcpus := make(map[instanceGroup]int)
+	vcpuUsage := make(map[string]int)
+	paginator := ec2.NewDescribeInstancesPaginator(ec2.NewFromConfig(c.Config), &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{{Name: aws.String("instance-state-name"), Values: []string{"pending", "running"}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape aws instances: %w", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				discovered++
+				purchase := "on-demand"
+				if instance.InstanceLifecycle == types.InstanceLifecycleTypeSpot {
+					purchase = "spot"
+				}
+				count := instanceVCPUs(instance)
+				if class := vcpuQuotaClass(string(instance.InstanceType), purchase); class != "" {
+					vcpuUsage[class] += count
+				}
+
+				tags := AWSTags(instance.Tags).Map()
+				if !rules.Match(tags, AWSTags(instance.Tags).Name()) {
+					continue
+				}
+				matched++
+				group := instanceGroup{
+					instanceType: string(instance.InstanceType),
+					nodePool:     labelValue(tags, nodePoolTags),
+					capacityType: purchase,
+				}
+				instances[group]++
+				vcpus[group] += count
+			}
+		}
+	}
+
+	metrics := discoveryMetrics("ec2", "instance", discovered, matched)
+	labels := []string{"instance_type", "node_pool", "capacity_type"}
+	for group, count := range instances {
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(
+				prometheus.NewDesc(prometheus.BuildFQName("aws", "ec2", "instances"), "Pending and running EC2 instances of the environment by type", labels, nil),
+				prometheus.GaugeValue,
+				float64(count),
+				group.instanceType, group.nodePool, group.capacityType),
+			prometheus.MustNewConstMetric(
+				prometheus.NewDesc(prometheus.BuildFQName("aws", "ec2", "vcpus"), "vCPUs of the pending and running EC2 instances of the environment by type", labels, nil),
+				prometheus.GaugeValue,
+				float64(vcpus[group]),
+				group.instanceType, group.nodePool, group.capacityType),
+		)
+	}
+
+	quotaMetrics, err := c.vcpuQuotaMetrics(ctx, vcpuUsage)
+	return append(metrics, quotaMetrics...), err
+}
+
+// vcpuQuotaMetrics reports the EC2 vCPU quotas of the account, e.g. Running
+// On-Demand Standard instances, with the headroom left by the running instances.
+func (c *AWSComputeCollector) vcpuQuotaMetrics(ctx context.Context, usage map[string]int) ([]prometheus.Metric, error) {
+	var metrics []prometheus.Metric
+	labels := []string{"quota_code", "quota_name", "class"}
+	paginator := servicequotas.NewListServiceQuotasPaginator(servicequotas.NewFromConfig(c.Config), &servicequotas.ListServiceQuotasInput{
+		ServiceCode: aws.String("ec2"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return metrics, fmt.Errorf("failed to scrape aws service quotas: %w", err)
+		}
+		for _, quota := range page.Quotas {
+			// vCPU quotas are measured per class, e.g. Standard/OnDemand or G/Spot
+			if quota.UsageMetric == nil || quota.UsageMetric.MetricDimensions["Resource"] != "vCPU" {
+				continue
+			}
+			class := quota.UsageMetric.MetricDimensions["Class"]
+			value := aws.ToFloat64(quota.Value)
+			values := []string{aws.ToString(quota.QuotaCode), aws.ToString(quota.QuotaName), class}
+			metrics = append(metrics,
+				prometheus.MustNewConstMetric(
+					prometheus.NewDesc(prometheus.BuildFQName("aws", "ec2", "vcpu_quota"), "EC2 vCPU quota of the account", labels, nil),
+					prometheus.GaugeValue,
+					value,
+					values...),
+				prometheus.MustNewConstMetric(
+					prometheus.NewDesc(prometheus.BuildFQName("aws", "ec2", "vcpu_quota_headroom"), "vCPUs left in an EC2 quota after the running instances of the account", labels, nil),
+					prometheus.GaugeValue,
+					value-float64(usage[class]),
+					values...),
+			)
+		}
+	}
+	return metrics, nil
+}
+
+func instanceVCPUs(instance types.Instance) int {
+	if instance.CpuOptions == nil {
+		return 0
+	}
+	return int(aws.ToInt32(instance.CpuOptions.CoreCount) * aws.ToInt32(instance.CpuOptions.ThreadsPerCore))
+}
+
+// vcpuQuotaFamilies are the instance families with a vCPU quota of their own,
+// the others of the standard families are in the Standard quota.
+var vcpuQuotaFamilies = []struct{ prefix, class string }{
+	{"dl", "DL"},
+	{"inf", "Inf"},
+	{"trn", "Trn"},
+	{"hpc", "HPC"},
+	{"vt", "G"},
+	{"g", "G"},
+	{"p", "P"},
+	{"x", "X"},
+	{"f", "F"},
+}
+
+// vcpuQuotaClass is the Class dimension of the vCPU quota an instance type counts
+// towards, e.g. Standard/OnDemand for m5.large, or empty when unknown.
+func vcpuQuotaClass(instanceType, purchase string) string {
+	option := "OnDemand"
+	if purchase == "spot" {
+		option = "Spot"
+	}
+	// mac instances only run on Dedicated Hosts, which have no vCPU quota
+	if strings.HasPrefix(instanceType, "mac") {
+		return ""
+	}
+	for _, family := range vcpuQuotaFamilies {
+		if strings.HasPrefix(instanceType, family.prefix) {
+			return family.class + "/" + option
+		}
+	}
+	if instanceType != "" && strings.ContainsRune("acdhimrtz", rune(instanceType[0])) {
+		return "Standard/" + option
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import "testing"
+
+func TestVCPUQuotaClass(t *testing.T) {
+	tests := []struct {
+		instanceType, purchase, want string
+	}{
+		{"m5.large", "on-demand", "Standard/OnDemand"},
+		{"c7g.xlarge", "spot", "Standard/Spot"},
+		{"t4g.medium", "", "Standard/OnDemand"},
+		{"g5.xlarge", "on-demand", "G/OnDemand"},
+		{"vt1.3xlarge", "on-demand", "G/OnDemand"},
+		{"p4d.24xlarge", "spot", "P/Spot"},
+		{"inf2.xlarge", "on-demand", "Inf/OnDemand"},
+		{"dl1.24xlarge", "on-demand", "DL/OnDemand"},
+		{"trn1.2xlarge", "on-demand", "Trn/OnDemand"},
+		{"hpc7g.16xlarge", "on-demand", "HPC/OnDemand"},
+		{"x2gd.xlarge", "on-demand", "X/OnDemand"},
+		{"f1.2xlarge", "on-demand", "F/OnDemand"},
+		{"u-6tb1.metal", "on-demand", ""},
+		{"mac2.metal", "on-demand", ""},
+		{"mac2-m2pro.metal", "on-demand", ""},
+		{"m7i-flex.large", "on-demand", "Standard/OnDemand"},
+		{"", "on-demand", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.instanceType+" "+tt.purchase, func(t *testing.T) {
+			if got := vcpuQuotaClass(tt.instanceType, tt.purchase); got != tt.want {
+				t.Errorf("vcpuQuotaClass(%q, %q) = %q, want %q", tt.instanceType, tt.purchase, got, tt.want)
+			}
+		})
+	}
+}
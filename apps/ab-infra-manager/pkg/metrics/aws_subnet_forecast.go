@@ -0,0 +1,169 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package metrics
+
+import (
+	"accelbyte/ab-infra-manager/pkg/persistence"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// minForecastSpan is the history needed before an exhaustion date is forecast,
+// so a burst of pods right after a restart is not extrapolated to days.
+const minForecastSpan = time.Hour
+
+// maxIPSamples bounds the samples of a key over the forecast window, e.g. one an
+// hour for a week. The history of every subnet then fits the usage persistence.
+const maxIPSamples = 168
+
+// ipSample is the number of used IPs of a subnet, or of the subnets of a VPC or
+// availability zone, at a refresh.
+type ipSample struct {
+	time time.Time
+	used float64
+}
+
+// ipHistory keeps the samples of every key for the forecast window, down-sampled
+// to maxIPSamples so it can be persisted. The zero value is ready.
+type ipHistory struct {
+	mu      sync.Mutex
+	samples map[string][]ipSample
+}
+
+// add records a sample of key and returns the samples of key within window,
+// oldest first. The last sample is replaced by the newer ones until it is
+// window/maxIPSamples after the one before.
+func (h *ipHistory) add(key string, sample ipSample, window time.Duration) []ipSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.samples == nil {
+		h.samples = make(map[string][]ipSample)
+	}
+	samples := h.samples[key]
+	if n := len(samples); n >= 2 && samples[n-1].time.Sub(samples[n-2].time) < window/maxIPSamples {
+		samples[n-1] = sample
+	} else {
+		samples = append(samples, sample)
+	}
+	samples = trimSamples(samples, sample.time.Add(-window))
+	h.samples[key] = samples
+	return append([]ipSample(nil), samples...)
+}
+
+// prune drops the keys without a sample since cutoff, e.g. deleted subnets.
+func (h *ipHistory) prune(cutoff time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, samples := range h.samples {
+		if samples = trimSamples(samples, cutoff); len(samples) == 0 {
+			delete(h.samples, key)
+		} else {
+			h.samples[key] = samples
+		}
+	}
+}
+
+// encodedSample is an ipSample as persisted, [Unix seconds, used IPs].
+type encodedSample [2]float64
+
+// MarshalJSON encodes the samples by key.
+func (h *ipHistory) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	encoded := make(map[string][]encodedSample, len(h.samples))
+	for key, samples := range h.samples {
+		for _, s := range samples {
+			encoded[key] = append(encoded[key], encodedSample{float64(s.time.Unix()), s.used})
+		}
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON replaces the samples with the encoded ones.
+func (h *ipHistory) UnmarshalJSON(data []byte) error {
+	var encoded map[string][]encodedSample
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	samples := make(map[string][]ipSample, len(encoded))
+	for key, values := range encoded {
+		for _, v := range values {
+			samples[key] = append(samples[key], ipSample{time: time.Unix(int64(v[0]), 0), used: v[1]})
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples = samples
+	return nil
+}
+
+// Restore loads the IP history kept by the previous pods from backend, later
+// samples are stored in backend. It is called before the first Scrape.
+func (c *AWSSubnetCollector) Restore(ctx context.Context, backend persistence.Backend) error {
+	c.persistence = backend
+	encoded, err := backend.Load(ctx)
+	if err != nil || len(encoded) == 0 {
+		return err
+	}
+	return json.Unmarshal(encoded[len(encoded)-1], &c.history)
+}
+
+// saveHistory persists the IP history at most once per sample interval. Failures
+// are logged, the forecast still works from memory.
+func (c *AWSSubnetCollector) saveHistory(ctx context.Context, now time.Time, window time.Duration) {
+	if c.persistence == nil || now.Sub(c.lastSave) < window/maxIPSamples {
+		return
+	}
+	data, err := json.Marshal(&c.history)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to encode the subnet IP history", "error", err)
+		return
+	}
+	// zero padded, so IDs sort by time
+	id := fmt.Sprintf("%020d", now.UnixNano())
+	if err := c.persistence.Put(ctx, id, data, 1); err != nil {
+		log.ErrorContext(ctx, "failed to persist the subnet IP history", "error", err, "bytes", len(data))
+		return
+	}
+	c.lastSave = now
+}
+
+// trimSamples drops the samples before cutoff.
+func trimSamples(samples []ipSample, cutoff time.Time) []ipSample {
+	for len(samples) > 0 && samples[0].time.Before(cutoff) {
+		samples = samples[1:]
+	}
+	return samples
+}
+
+// exhaustionDays forecasts the days until available IPs run out, from a linear
+// regression of the used IPs over the samples. ok is false without enough
+// history or when usage is not growing.
+func exhaustionDays(samples []ipSample, available float64) (days float64, ok bool) {
+	if len(samples) < 2 || samples[len(samples)-1].time.Sub(samples[0].time) < minForecastSpan {
+		return 0, false
+	}
+	var meanX, meanY float64
+	for _, s := range samples {
+		meanX += s.time.Sub(samples[0].time).Seconds()
+		meanY += s.used
+	}
+	meanX /= float64(len(samples))
+	meanY /= float64(len(samples))
+	var covariance, variance float64
+	for _, s := range samples {
+		dx := s.time.Sub(samples[0].time).Seconds() - meanX
+		covariance += dx * (s.used - meanY)
+		variance += dx * dx
+	}
+	perDay := covariance / variance * (24 * time.Hour).Seconds()
+	if perDay <= 0 {
+		return 0, false
+	}
+	return available / perDay, true
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"accelbyte/ab-infra-manager/pkg/persistence"
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// hourly returns a sample per hour with the used IPs of values.
+func hourly(start time.Time, values ...float64) []ipSample {
+	samples := make([]ipSample, 0, len(values))
+	for i, used := range values {
+		samples = append(samples, ipSample{time: start.Add(time.Duration(i) * time.Hour), used: used})
+	}
+	return samples
+}
+
+func TestExhaustionDays(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		samples   []ipSample
+		available float64
+		wantOK    bool
+		wantDays  float64
+	}{
+		{"no samples", nil, 100, false, 0},
+		{"single sample", hourly(start, 10), 100, false, 0},
+		{"history shorter than the minimum span", []ipSample{{start, 10}, {start.Add(30 * time.Minute), 20}}, 100, false, 0},
+		{"flat", hourly(start, 50, 50, 50, 50), 100, false, 0},
+		{"shrinking", hourly(start, 80, 70, 60, 50), 100, false, 0},
+		// one IP an hour is 24 a day
+		{"growing", hourly(start, 10, 11, 12, 13), 48, true, 2},
+		{"noisy growth", hourly(start, 10, 12, 11, 13), 96, true, 96 / (0.8 * 24)},
+		{"exhausted", hourly(start, 10, 11), 0, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, ok := exhaustionDays(tt.samples, tt.available)
+			if ok != tt.wantOK {
+				t.Fatalf("exhaustionDays() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(days-tt.wantDays) > 1e-9 {
+				t.Errorf("exhaustionDays() = %v days, want %v", days, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestIPHistory(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	window := 2 * time.Hour
+	var h ipHistory
+
+	for i, used := range []float64{1, 2, 3, 4} {
+		h.add("subnet/a", ipSample{start.Add(time.Duration(i) * time.Hour), used}, window)
+	}
+	samples := h.add("subnet/b", ipSample{start.Add(3 * time.Hour), 7}, window)
+	if len(samples) != 1 || samples[0].used != 7 {
+		t.Errorf("add(subnet/b) = %v", samples)
+	}
+
+	// samples older than the window are dropped, the returned slice is a copy
+	samples = h.add("subnet/a", ipSample{start.Add(4 * time.Hour), 5}, window)
+	if len(samples) != 3 || samples[0].used != 3 || samples[2].used != 5 {
+		t.Fatalf("add(subnet/a) = %v, want the samples of hours 2 to 4", samples)
+	}
+	samples[0].used = 100
+	if h.samples["subnet/a"][0].used != 3 {
+		t.Error("add returned the stored samples")
+	}
+
+	// subnet/b was not seen since hour 3
+	h.prune(start.Add(4 * time.Hour))
+	if _, ok := h.samples["subnet/b"]; ok {
+		t.Error("prune kept a key without recent samples")
+	}
+	if got := len(h.samples["subnet/a"]); got != 1 {
+		t.Errorf("prune kept %d samples of subnet/a, want 1", got)
+	}
+}
+
+func TestIPHistoryDownsampling(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	window := 7 * 24 * time.Hour
+	var h ipHistory
+
+	// a week of refreshes every 5 minutes keeps about one sample an hour
+	var samples []ipSample
+	for i := 0; i <= 7*24*12; i++ {
+		samples = h.add("subnet/a", ipSample{start.Add(time.Duration(i) * 5 * time.Minute), float64(i)}, window)
+	}
+	if len(samples) > maxIPSamples+2 {
+		t.Errorf("kept %d samples, want at most %d", len(samples), maxIPSamples+2)
+	}
+	if last := samples[len(samples)-1]; last.used != 7*24*12 {
+		t.Errorf("last sample = %v, want the newest refresh", last)
+	}
+	for i := 1; i < len(samples)-1; i++ {
+		if gap := samples[i].time.Sub(samples[i-1].time); gap < window/maxIPSamples {
+			t.Fatalf("samples %d and %d are %v apart", i-1, i, gap)
+		}
+	}
+}
+
+func TestIPHistoryPersistence(t *testing.T) {
+	ctx := context.Background()
+	backend, err := persistence.OpenBolt(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	window := 7 * 24 * time.Hour
+
+	c := &AWSSubnetCollector{}
+	if err := c.Restore(ctx, backend.Sub("ip-history")); err != nil {
+		t.Fatal(err)
+	}
+	for i, used := range []float64{10, 11, 12} {
+		now := start.Add(time.Duration(i) * time.Hour)
+		c.history.add("subnet/a", ipSample{now, used}, window)
+		c.saveHistory(ctx, now, window)
+	}
+	// saved at most once per sample interval
+	c.history.add("subnet/a", ipSample{start.Add(2*time.Hour + 5*time.Minute), 13}, window)
+	c.saveHistory(ctx, start.Add(2*time.Hour+5*time.Minute), window)
+
+	// a new pod forecasts from the history of the previous one
+	restarted := &AWSSubnetCollector{}
+	if err := restarted.Restore(ctx, backend.Sub("ip-history")); err != nil {
+		t.Fatal(err)
+	}
+	samples := restarted.history.add("subnet/a", ipSample{start.Add(3 * time.Hour), 13}, window)
+	if len(samples) != 4 || !samples[0].time.Equal(start) || samples[2].used != 12 {
+		t.Fatalf("restored samples = %v", samples)
+	}
+	if days, ok := exhaustionDays(samples, 48); !ok || math.Abs(days-2) > 1e-9 {
+		t.Errorf("exhaustionDays() = %v, %v, want 2 days", days, ok)
+	}
+}
@@ -1,10 +1,19 @@
 package metrics
 
 import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/ownership"
+	"accelbyte/ab-infra-manager/pkg/persistence"
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"log/slog"
-	"math"
-	"net"
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -17,72 +26,300 @@ var (
 )
 
 type AWSSubnetCollector struct {
-	Config          aws.Config
+	Config aws.Config
+	// AppConfig returns the configuration in effect, it is read once per scrape.
+	AppConfig       func() *models.Cfg
 	CustomerName    string
 	EnvironmentName string
 	ProjectName     string
-}
 
-func (c *AWSSubnetCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(c, ch)
+	// history of the used IPs, for the exhaustion forecast
+	history ipHistory
+	// persistence keeps history across restarts, see Restore
+	persistence persistence.Backend
+	lastSave    time.Time
 }
 
-func (c *AWSSubnetCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+// reservedIPs are the addresses AWS reserves in every subnet: the network
+// address, the VPC router, DNS, one for future use and the broadcast address.
+const reservedIPs = 5
 
-	for _, metric := range c.scrape(ctx) {
-		ch <- metric
-	}
-}
+// prefixBits is the size of the prefixes assigned to network interfaces with
+// prefix delegation, e.g. by the VPC CNI with ENABLE_PREFIX_DELEGATION.
+const prefixBits = 28
 
-func (c *AWSSubnetCollector) scrape(ctx context.Context) []prometheus.Metric {
+// describeFilterValues is the number of subnets per DescribeNetworkInterfaces call.
+const describeFilterValues = 200
+
+// Scrape reads the subnets of the environment, it runs in the background of a Refresher.
+func (c *AWSSubnetCollector) Scrape(ctx context.Context) ([]prometheus.Metric, error) {
 	var metrics []prometheus.Metric
-	o, err := ec2.NewFromConfig(c.Config).DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("tag:customer_name"),
-				Values: []string{c.CustomerName},
-			},
-			{
-				Name:   aws.String("tag:environment_name"),
-				Values: []string{c.EnvironmentName},
-			},
-			{
-				Name:   aws.String("tag:project"),
-				Values: []string{c.ProjectName},
-			},
-		},
-	})
+	cfg := c.AppConfig()
+	rules, err := ownership.NewRules(cfg.Ownership, map[string]string{
+		"customer_name":    c.CustomerName,
+		"project":          c.ProjectName,
+		"environment_name": c.EnvironmentName,
+	}, fmt.Sprintf("%s-%s-%s", c.CustomerName, c.ProjectName, c.EnvironmentName))
+	if err != nil {
+		return nil, err
+	}
+	client := ec2.NewFromConfig(c.Config)
+
+	// every subnet is listed, so untagged ones can still match by name
+	var all, subnets []types.Subnet
+	paginator := ec2.NewDescribeSubnetsPaginator(client, &ec2.DescribeSubnetsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape aws subnet: %w", err)
+		}
+		all = append(all, page.Subnets...)
+		for _, subnet := range page.Subnets {
+			if rules.Match(AWSTags(subnet.Tags).Map(), AWSTags(subnet.Tags).Name()) {
+				subnets = append(subnets, subnet)
+			}
+		}
+	}
+	metrics = append(metrics, discoveryMetrics("ec2", "subnet", len(all), len(subnets))...)
+
+	// the metrics of the subnets are kept when the extra calls fail
+	var errs []error
+	freePrefixes, err := freePrefixes(ctx, client, subnets)
 	if err != nil {
-		log.Error("failed to scrape aws subnet", "error", err)
-		return nil
+		errs = append(errs, err)
 	}
 
-	for _, subnet := range o.Subnets {
+	now := time.Now()
+	window := cfg.AWSSubnetForecastWindow
+	vpcs := make(map[string]*ipCapacity)
+	zones := make(map[[2]string]*ipCapacity)
+	for _, subnet := range subnets {
 		name := AWSTags(subnet.Tags).Name()
 		subnetid := aws.ToString(subnet.SubnetId)
 		cidr := aws.ToString(subnet.CidrBlock)
 		vpcid := aws.ToString(subnet.VpcId)
 		az := aws.ToString(subnet.AvailabilityZone)
+		labels := []string{name, cidr, subnetid, vpcid, az}
+
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			log.DebugContext(ctx, "skipping subnet without an IPv4 cidr", "subnet_id", subnetid)
+			continue
+		}
+		subnetCapacity := ipCapacity{
+			maxHosts:  float64(addresses(prefix) - reservedIPs),
+			available: float64(aws.ToInt32(subnet.AvailableIpAddressCount)),
+		}
+		metrics = append(metrics, subnetCapacity.metrics("subnet", subnetLabels, labels,
+			c.history.add("subnet/"+subnetid, subnetCapacity.sample(now), window))...)
+		if free, ok := freePrefixes[subnetid]; ok {
+			metrics = append(metrics, prometheus.MustNewConstMetric(
+				prometheus.NewDesc(prometheus.BuildFQName("aws", "subnet", "available_prefixes"), "Free /28 prefixes for network interfaces with prefix delegation", subnetLabels, nil),
+				prometheus.GaugeValue,
+				float64(free),
+				labels...))
+		}
+
+		vpcs[vpcid] = vpcs[vpcid].add(subnetCapacity)
+		zones[[2]string{vpcid, az}] = zones[[2]string{vpcid, az}].add(subnetCapacity)
+	}
+
+	for vpcid, capacity := range vpcs {
+		metrics = append(metrics, capacity.metrics("vpc", []string{"vpc_id"}, []string{vpcid},
+			c.history.add("vpc/"+vpcid, capacity.sample(now), window))...)
+	}
+	for zone, capacity := range zones {
+		metrics = append(metrics, capacity.metrics("vpc_az", []string{"vpc_id", "availability_zone"}, zone[:],
+			c.history.add("vpc_az/"+zone[0]+"/"+zone[1], capacity.sample(now), window))...)
+	}
+	c.history.prune(now.Add(-window))
+	c.saveHistory(ctx, now, window)
+
+	cidrMetrics, err := cidrBlockMetrics(ctx, client, slices.Collect(maps.Keys(vpcs)), all)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	metrics = append(metrics, cidrMetrics...)
+
+	return metrics, errors.Join(errs...)
+}
+
+var subnetLabels = []string{"name", "cidr", "subnet_id", "vpc_id", "availability_zone"}
+
+// ipCapacity is the IP addresses of a subnet, or of the subnets of a VPC or an
+// availability zone.
+type ipCapacity struct {
+	maxHosts  float64
+	available float64
+}
+
+// add returns the sum of c and other, c can be nil.
+func (c *ipCapacity) add(other ipCapacity) *ipCapacity {
+	if c == nil {
+		return &other
+	}
+	return &ipCapacity{maxHosts: c.maxHosts + other.maxHosts, available: c.available + other.available}
+}
+
+func (c ipCapacity) sample(now time.Time) ipSample {
+	return ipSample{time: now, used: c.maxHosts - c.available}
+}
+
+// metrics reports the capacity as aws_<subsystem>_*, with the days until it is
+// exhausted when samples show a growing usage.
+func (c ipCapacity) metrics(subsystem string, labels, values []string, samples []ipSample) []prometheus.Metric {
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(
+			prometheus.NewDesc(prometheus.BuildFQName("aws", subsystem, "available_ip"), "Available IP addresses", labels, nil),
+			prometheus.GaugeValue,
+			c.available,
+			values...),
+		prometheus.MustNewConstMetric(
+			prometheus.NewDesc(prometheus.BuildFQName("aws", subsystem, "max_hosts"), "Usable IP addresses, without the 5 reserved by AWS in every subnet", labels, nil),
+			prometheus.GaugeValue,
+			c.maxHosts,
+			values...),
+	}
+	if c.maxHosts > 0 {
 		metrics = append(metrics, prometheus.MustNewConstMetric(
-			prometheus.NewDesc(prometheus.BuildFQName("aws", "subnet", "available_ip"), "Available subnet IP", []string{"name", "cidr", "subnet_id", "vpc_id", "availability_zone"}, nil),
+			prometheus.NewDesc(prometheus.BuildFQName("aws", subsystem, "ip_utilization_ratio"), "Used share of the usable IP addresses, from 0 to 1", labels, nil),
 			prometheus.GaugeValue,
-			float64(aws.ToInt32(subnet.AvailableIpAddressCount)),
-			name, cidr, subnetid, vpcid, az))
-
-		_, ipv4, _ := net.ParseCIDR(aws.ToString(subnet.CidrBlock))
-		size, _ := ipv4.Mask.Size()
-		maxHosts := float64(math.Pow(2, float64(32-size))) - 2
+			(c.maxHosts-c.available)/c.maxHosts,
+			values...))
+	}
+	if days, ok := exhaustionDays(samples, c.available); ok {
 		metrics = append(metrics, prometheus.MustNewConstMetric(
-			prometheus.NewDesc(prometheus.BuildFQName("aws", "subnet", "max_hosts"), "Maximum IP address of AWS Subnet", []string{"name", "cidr", "subnet_id", "vpc_id", "availability_zone"}, nil),
+			prometheus.NewDesc(prometheus.BuildFQName("aws", subsystem, "ip_exhaustion_days"), "Days until the available IP addresses run out at the growth of the forecast window, only while growing", labels, nil),
 			prometheus.GaugeValue,
-			maxHosts,
-			name, cidr, subnetid, vpcid, az))
+			days,
+			values...))
 	}
-
 	return metrics
 }
 
+// freePrefixes counts the /28 blocks of each subnet that no network interface
+// uses, by subnet id. Prefix delegation needs a whole free block, so a
+// fragmented subnet runs out of prefixes long before it runs out of IPs.
+func freePrefixes(ctx context.Context, client *ec2.Client, subnets []types.Subnet) (map[string]int, error) {
+	ids := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		ids = append(ids, aws.ToString(subnet.SubnetId))
+	}
+
+	used := make(map[string]map[uint32]bool)
+	for chunk := range slices.Chunk(ids, describeFilterValues) {
+		paginator := ec2.NewDescribeNetworkInterfacesPaginator(client, &ec2.DescribeNetworkInterfacesInput{
+			Filters: []types.Filter{{Name: aws.String("subnet-id"), Values: chunk}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scrape aws network interfaces: %w", err)
+			}
+			for _, eni := range page.NetworkInterfaces {
+				subnetid := aws.ToString(eni.SubnetId)
+				if used[subnetid] == nil {
+					used[subnetid] = make(map[uint32]bool)
+				}
+				for _, ip := range eni.PrivateIpAddresses {
+					if addr, err := netip.ParseAddr(aws.ToString(ip.PrivateIpAddress)); err == nil && addr.Is4() {
+						used[subnetid][block(addr)] = true
+					}
+				}
+				for _, prefix := range eni.Ipv4Prefixes {
+					if p, err := netip.ParsePrefix(aws.ToString(prefix.Ipv4Prefix)); err == nil {
+						used[subnetid][block(p.Addr())] = true
+					}
+				}
+			}
+		}
+	}
+
+	free := make(map[string]int, len(subnets))
+	for _, subnet := range subnets {
+		subnetid := aws.ToString(subnet.SubnetId)
+		prefix, err := netip.ParsePrefix(aws.ToString(subnet.CidrBlock))
+		if err != nil {
+			continue
+		}
+		free[subnetid] = 0
+		if prefix.Bits() > prefixBits {
+			continue
+		}
+		// the first and last blocks hold the addresses reserved by AWS
+		first := block(prefix.Masked().Addr())
+		last := first + uint32(1)<<(prefixBits-prefix.Bits()) - 1
+		for b := first + 1; b < last; b++ {
+			if !used[subnetid][b] {
+				free[subnetid]++
+			}
+		}
+	}
+	return free, nil
+}
+
+// cidrBlockMetrics reports the size of the primary and secondary CIDR blocks of
+// the VPCs and how much of it is allocated to subnets, e.g. the 100.64.0.0/16 of
+// EKS custom networking. subnets are every subnet of the account.
+func cidrBlockMetrics(ctx context.Context, client *ec2.Client, vpcids []string, subnets []types.Subnet) ([]prometheus.Metric, error) {
+	if len(vpcids) == 0 {
+		return nil, nil
+	}
+	var metrics []prometheus.Metric
+	labels := []string{"vpc_id", "cidr", "primary"}
+	paginator := ec2.NewDescribeVpcsPaginator(client, &ec2.DescribeVpcsInput{VpcIds: vpcids})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return metrics, fmt.Errorf("failed to scrape aws vpc: %w", err)
+		}
+		for _, vpc := range page.Vpcs {
+			vpcid := aws.ToString(vpc.VpcId)
+			for _, association := range vpc.CidrBlockAssociationSet {
+				if association.CidrBlockState == nil || association.CidrBlockState.State != types.VpcCidrBlockStateCodeAssociated {
+					continue
+				}
+				cidr := aws.ToString(association.CidrBlock)
+				cidrBlock, err := netip.ParsePrefix(cidr)
+				if err != nil {
+					continue
+				}
+				var allocated uint64
+				for _, subnet := range subnets {
+					prefix, err := netip.ParsePrefix(aws.ToString(subnet.CidrBlock))
+					if err == nil && aws.ToString(subnet.VpcId) == vpcid && cidrBlock.Contains(prefix.Addr()) {
+						allocated += addresses(prefix)
+					}
+				}
+				primary := strconv.FormatBool(cidr == aws.ToString(vpc.CidrBlock))
+				metrics = append(metrics,
+					prometheus.MustNewConstMetric(
+						prometheus.NewDesc(prometheus.BuildFQName("aws", "vpc", "cidr_block_ips"), "IP addresses of a VPC CIDR block", labels, nil),
+						prometheus.GaugeValue,
+						float64(addresses(cidrBlock)),
+						vpcid, cidr, primary),
+					prometheus.MustNewConstMetric(
+						prometheus.NewDesc(prometheus.BuildFQName("aws", "vpc", "cidr_block_allocated_ips"), "IP addresses of a VPC CIDR block allocated to subnets", labels, nil),
+						prometheus.GaugeValue,
+						float64(allocated),
+						vpcid, cidr, primary))
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// addresses is the number of addresses of an IPv4 prefix.
+func addresses(prefix netip.Prefix) uint64 {
+	return 1 << (32 - prefix.Bits())
+}
+
+// block is the index of the /28 block of an IPv4 address.
+func block(addr netip.Addr) uint32 {
+	ip := addr.As4()
+	return binary.BigEndian.Uint32(ip[:]) >> (32 - prefixBits)
+}
+
 type AWSTags []types.Tag
 
 func (t AWSTags) Name() string {
@@ -95,3 +332,30 @@ func (t AWSTags) Name() string {
 	}
 	return name
 }
+
+// Map returns the tags by key.
+func (t AWSTags) Map() map[string]string {
+	tags := make(map[string]string, len(t))
+	for _, tag := range t {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags
+}
+
+// discoveryMetrics reports how many resources of a service were listed and how
+// many of them belong to the environment.
+func discoveryMetrics(service, resource string, discovered, matched int) []prometheus.Metric {
+	labels := []string{"service", "resource"}
+	return []prometheus.Metric{
+		prometheus.MustNewConstMetric(
+			prometheus.NewDesc(prometheus.BuildFQName("ab_infra_manager", "aws_resources", "discovered"), "Resources listed from the AWS API", labels, nil),
+			prometheus.GaugeValue,
+			float64(discovered),
+			service, resource),
+		prometheus.MustNewConstMetric(
+			prometheus.NewDesc(prometheus.BuildFQName("ab_infra_manager", "aws_resources", "matched"), "Listed resources that belong to the environment", labels, nil),
+			prometheus.GaugeValue,
+			float64(matched),
+			service, resource),
+	}
+}
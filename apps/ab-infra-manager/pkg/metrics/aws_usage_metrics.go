@@ -5,11 +5,16 @@
 package metrics
 
 import (
+	"accelbyte/ab-infra-manager/pkg/awserr"
+	"accelbyte/ab-infra-manager/pkg/cache"
 	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/persistence"
+	"accelbyte/ab-infra-manager/pkg/recommend"
 	"accelbyte/ab-infra-manager/pkg/usagestore"
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -21,94 +26,96 @@ type AWSUsageCollector struct {
 	EnvironmentName string
 	ProjectName     string
 
+	config          func() *models.Cfg
 	awsUsageStorage *usagestore.UsageStore
-	isStopFetching  bool // a flag that will be set to true if we got 401 or 403 which means misconfiguration and avoid unecessary aws api call
+	refreshMu       sync.Mutex
+	lastRefresh     time.Time
+	// Backoff is shared with the Refresher, so the usage API does not call AWS
+	// while it throttles or denies access.
+	Backoff *awserr.Backoff
 }
 
-func NewAWSUsageCollector(config models.Cfg, awsConfig aws.Config, customerName, environtmentName, projectName string) *AWSUsageCollector {
+func NewAWSUsageCollector(config func() *models.Cfg, awsConfig aws.Config, cacheMetrics *cache.Metrics, customerName, environtmentName, projectName string) *AWSUsageCollector {
 	return &AWSUsageCollector{
 		CustomerName:    customerName,
 		EnvironmentName: environtmentName,
 		ProjectName:     projectName,
+		config:          config,
+		Backoff:         awserr.NewBackoff(),
 		awsUsageStorage: usagestore.New(config, awsConfig, map[string]string{
 			"customer_name":    customerName,
 			"project":          projectName,
 			"environment_name": environtmentName,
-		}, fmt.Sprintf("%s-%s-%s", customerName, projectName, environtmentName)),
+		}, fmt.Sprintf("%s-%s-%s", customerName, projectName, environtmentName), cacheMetrics),
 	}
 }
 
-func (u *AWSUsageCollector) ValidateFetchable(err error) {
-	if strings.Contains(err.Error(), "StatusCode: 403") || strings.Contains(err.Error(), "StatusCode: 401") {
-		u.isStopFetching = true
-	}
+// Reset drops the cached usage, so it is fetched again with the current config.
+func (u *AWSUsageCollector) Reset() {
+	u.awsUsageStorage.Reset()
+}
+
+// Restore loads the usage snapshots kept by backend, see usagestore.UsageStore.Restore.
+func (u *AWSUsageCollector) Restore(ctx context.Context, backend persistence.Backend) error {
+	return u.awsUsageStorage.Restore(ctx, backend)
 }
-func (u *AWSUsageCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(u, ch)
+
+// History returns the usage of the retained windows, oldest first.
+func (u *AWSUsageCollector) History() []usagestore.Snapshot {
+	return u.awsUsageStorage.History()
 }
 
-func (u *AWSUsageCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+// minRefreshInterval limits how often the usage API can force a rescrape.
+const minRefreshInterval = time.Minute
 
-	for _, metric := range u.scrape(ctx) {
-		ch <- metric
+// Usage returns the cached usage of every instance. With refresh, the cache is
+// dropped first so everything is fetched again from AWS.
+func (u *AWSUsageCollector) Usage(ctx context.Context, refresh bool) ([]usagestore.Usage, error) {
+	if !u.Backoff.Allow(time.Now()) {
+		status := u.Backoff.Status()
+		return nil, fmt.Errorf("backing off after %s until %s: %s", status.State, status.RetryAt.Format(time.RFC3339), status.LastError)
 	}
+	if refresh {
+		u.refreshMu.Lock()
+		if time.Since(u.lastRefresh) >= minRefreshInterval {
+			u.awsUsageStorage.Reset()
+			u.lastRefresh = time.Now()
+		}
+		u.refreshMu.Unlock()
+	}
+	usages, err := u.awsUsageStorage.GetUsage(ctx)
+	u.Backoff.Observe(err, time.Now())
+	return usages, err
 }
 
-func (u *AWSUsageCollector) scrape(ctx context.Context) []prometheus.Metric {
-	var collectedMetrics []prometheus.Metric
-	if u.isStopFetching {
-		log.ErrorContext(ctx, "configuration error, invalid AWS credentials")
-		return collectedMetrics
-	}
-	instances, err := u.awsUsageStorage.GetInstances(ctx)
-	if err != nil {
-		u.ValidateFetchable(err)
+// Recommendations proposes a size for every instance from its cached usage.
+func (u *AWSUsageCollector) Recommendations(ctx context.Context) ([]recommend.Recommendation, error) {
+	usages, err := u.Usage(ctx, false)
+	if err != nil && len(usages) == 0 {
+		return nil, err
 	}
-	for _, instance := range instances {
-		identifier := instance.Identifier
-		if instance.Level == "Broker" {
-			identifier = identifier + "-" + instance.BrokerID
-		}
-		maxCPUUsage, err := u.awsUsageStorage.GetMaxCPUUsage(ctx, identifier, instance)
-		if err != nil {
-			u.ValidateFetchable(err)
-			log.ErrorContext(ctx, "failed to get db usage",
-				"error", err,
-				"identifier", instance.Identifier,
-				"engine", instance.Engine,
-				"level", instance.Level,
-				"identifier_field_name", instance.IdentifierFieldName,
-				"instance_class", instance.InstanceClass,
-			)
-			continue
-		}
-		collectedMetrics = append(collectedMetrics, prometheus.MustNewConstMetric(
-			prometheus.NewDesc(
-				prometheus.BuildFQName("aws", "resource_usage", "cpu_max"), "Resource peak CPU Usage",
-				[]string{"identifier", "instance_class", "engine", "level", "collected_time", "environment"},
-				nil,
-			),
-			prometheus.GaugeValue,
-			maxCPUUsage,
-			identifier, instance.InstanceClass, instance.Engine, instance.Level, instance.CollectedTime.Format(time.RFC3339),
-			fmt.Sprintf("%s-%s-%s", u.CustomerName, u.ProjectName, u.EnvironmentName),
-		))
+	return recommend.Recommend(usages, u.config().Recommendation), nil
+}
 
-		if instance.Engine == "redis" || instance.Engine == "valkey" {
-			maxMemoryUsage, err := u.awsUsageStorage.GetElastiCacheMaxMemoryUsage(ctx, identifier, instance)
-			if err != nil {
-				u.ValidateFetchable(err)
-				log.ErrorContext(ctx, "failed to get db usage",
-					"identifier", instance.Identifier,
-					"error", err,
-					"engine", instance.Engine,
-					"level", instance.Level,
-					"identifier_field_name", instance.IdentifierFieldName,
-					"instance_class", instance.InstanceClass,
-				)
-				continue
-			}
+// Scrape reads the usage of every instance, it runs in the background of a Refresher.
+func (u *AWSUsageCollector) Scrape(ctx context.Context) ([]prometheus.Metric, error) {
+	var collectedMetrics []prometheus.Metric
+	usages, err := u.awsUsageStorage.GetUsage(ctx)
+	for _, usage := range usages {
+		if usage.MaxCPU != nil {
+			collectedMetrics = append(collectedMetrics, prometheus.MustNewConstMetric(
+				prometheus.NewDesc(
+					prometheus.BuildFQName("aws", "resource_usage", "cpu_max"), "Resource peak CPU Usage",
+					[]string{"identifier", "instance_class", "engine", "level", "collected_time", "environment"},
+					nil,
+				),
+				prometheus.GaugeValue,
+				*usage.MaxCPU,
+				usage.Key, usage.InstanceClass, usage.Engine, usage.Level, usage.CollectedTime.Format(time.RFC3339),
+				fmt.Sprintf("%s-%s-%s", u.CustomerName, u.ProjectName, u.EnvironmentName),
+			))
+		}
+		if usage.MaxMemory != nil {
 			collectedMetrics = append(collectedMetrics, prometheus.MustNewConstMetric(
 				prometheus.NewDesc(
 					prometheus.BuildFQName("aws", "resource_usage", "memory_max"), "Resource peak memory Usage",
@@ -116,12 +123,75 @@ func (u *AWSUsageCollector) scrape(ctx context.Context) []prometheus.Metric {
 					nil,
 				),
 				prometheus.GaugeValue,
-				maxMemoryUsage,
-				identifier, instance.InstanceClass, instance.Engine, instance.Level, instance.CollectedTime.Format(time.RFC3339),
+				*usage.MaxMemory,
+				usage.Key, usage.InstanceClass, usage.Engine, usage.Level, usage.CollectedTime.Format(time.RFC3339),
+				fmt.Sprintf("%s-%s-%s", u.CustomerName, u.ProjectName, u.EnvironmentName),
+			))
+		}
+	}
+
+	for _, usage := range usages {
+		for statistic, value := range usage.CPU {
+			collectedMetrics = append(collectedMetrics, prometheus.MustNewConstMetric(
+				prometheus.NewDesc(
+					prometheus.BuildFQName("aws", "resource_usage", "cpu"), "Resource CPU usage statistic over the usage time range",
+					[]string{"identifier", "instance_class", "engine", "level", "statistic", "collected_time", "environment"},
+					nil,
+				),
+				prometheus.GaugeValue,
+				value,
+				usage.Key, usage.InstanceClass, usage.Engine, usage.Level, statistic, usage.CollectedTime.Format(time.RFC3339),
+				fmt.Sprintf("%s-%s-%s", u.CustomerName, u.ProjectName, u.EnvironmentName),
+			))
+		}
+		for statistic, value := range usage.Memory {
+			collectedMetrics = append(collectedMetrics, prometheus.MustNewConstMetric(
+				prometheus.NewDesc(
+					prometheus.BuildFQName("aws", "resource_usage", "memory"), "Resource memory usage statistic over the usage time range",
+					[]string{"identifier", "instance_class", "engine", "level", "statistic", "collected_time", "environment"},
+					nil,
+				),
+				prometheus.GaugeValue,
+				value,
+				usage.Key, usage.InstanceClass, usage.Engine, usage.Level, statistic, usage.CollectedTime.Format(time.RFC3339),
 				fmt.Sprintf("%s-%s-%s", u.CustomerName, u.ProjectName, u.EnvironmentName),
 			))
 		}
+		// e.g. aws_resource_usage_storage for OpenSearch or aws_resource_usage_capacity_acu for Aurora Serverless
+		for series, values := range usage.Series {
+			for statistic, value := range values {
+				collectedMetrics = append(collectedMetrics, prometheus.MustNewConstMetric(
+					prometheus.NewDesc(
+						prometheus.BuildFQName("aws", "resource_usage", series), "Resource "+strings.ReplaceAll(series, "_", " ")+" statistic over the usage time range",
+						[]string{"identifier", "instance_class", "engine", "level", "statistic", "collected_time", "environment"},
+						nil,
+					),
+					prometheus.GaugeValue,
+					value,
+					usage.Key, usage.InstanceClass, usage.Engine, usage.Level, statistic, usage.CollectedTime.Format(time.RFC3339),
+					fmt.Sprintf("%s-%s-%s", u.CustomerName, u.ProjectName, u.EnvironmentName),
+				))
+			}
+		}
+	}
+
+	for _, discovery := range u.awsUsageStorage.Discovery() {
+		collectedMetrics = append(collectedMetrics, discoveryMetrics(discovery.Service, discovery.Resource, discovery.Discovered, discovery.Matched)...)
+	}
+
+	for _, r := range recommend.Recommend(usages, u.config().Recommendation) {
+		collectedMetrics = append(collectedMetrics, prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("aws", "resource", "recommendation"), "Rightsizing recommendation: -1 downsize, 0 keep, 1 upsize",
+				[]string{"identifier", "instance_class", "recommended_class", "engine", "level", "action", "environment"},
+				nil,
+			),
+			prometheus.GaugeValue,
+			r.Direction(),
+			r.Identifier, r.InstanceClass, r.RecommendedClass, r.Engine, r.Level, r.Action,
+			fmt.Sprintf("%s-%s-%s", u.CustomerName, u.ProjectName, u.EnvironmentName),
+		))
 	}
 
-	return collectedMetrics
+	return collectedMetrics, err
 }
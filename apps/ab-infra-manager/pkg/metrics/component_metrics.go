@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ComponentMetrics exposes every tracked Kustomization in the same metric families,
+// with the Kustomization name as the resource label.
+type ComponentMetrics struct {
+	Info          *prometheus.GaugeVec
+	Ready         *prometheus.GaugeVec
+	LastReconcile *prometheus.GaugeVec
+}
+
+var componentLabels = []string{"environment", "aws_account_id", "aws_region", "resource"}
+
+func NewComponentMetrics(reg *prometheus.Registry) *ComponentMetrics {
+	m := &ComponentMetrics{
+		Info: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "component",
+			Name:      "info",
+			Help:      "Deployed component version as labels, with the infra CCU setup/tier based on AccelByte's MSA doc as value",
+		}, append(componentLabels, "version", "patch", "revision", "live")),
+		Ready: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "component",
+			Name:      "ready",
+			Help:      "1 if the Ready condition of the component's Kustomization is True, 0 otherwise",
+		}, componentLabels),
+		// Flux records no time for the periodic reconciles of a healthy Kustomization,
+		// only for Ready changes and requested reconciles
+		LastReconcile: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "component",
+			Name:      "last_reconcile_timestamp",
+			Help:      "Unix time of the last Ready status change or handled reconcile request of the component's Kustomization",
+		}, componentLabels),
+	}
+	reg.MustRegister(m.Info, m.Ready, m.LastReconcile)
+	return m
+}
+
+// Delete removes every series of a component.
+func (m *ComponentMetrics) Delete(resource string) {
+	labels := prometheus.Labels{"resource": resource}
+	m.Info.DeletePartialMatch(labels)
+	m.Ready.DeletePartialMatch(labels)
+	m.LastReconcile.DeletePartialMatch(labels)
+}
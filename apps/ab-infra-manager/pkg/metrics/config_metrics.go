@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ConfigMetrics reports the outcome of live configuration reloads.
+type ConfigMetrics struct {
+	LastReloadSuccessful       prometheus.Gauge
+	LastReloadSuccessTimestamp prometheus.Gauge
+}
+
+func NewConfigMetrics(reg *prometheus.Registry) *ConfigMetrics {
+	m := &ConfigMetrics{
+		LastReloadSuccessful: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "config",
+			Name:      "last_reload_successful",
+			Help:      "Whether the last configuration reload attempt was successful",
+		}),
+		LastReloadSuccessTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "config",
+			Name:      "last_reload_success_timestamp_seconds",
+			Help:      "Timestamp of the last successful configuration reload",
+		}),
+	}
+	reg.MustRegister(m.LastReloadSuccessful, m.LastReloadSuccessTimestamp)
+	return m
+}
@@ -7,28 +7,34 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// Metrics is the legacy gauge with one metric name per Kustomization, only
+// registered when LEGACY_KUSTOMIZATION_METRICS is enabled.
 type Metrics struct {
 	CCU *prometheus.GaugeVec
 }
 
-func NewSingleMetrics(reg *prometheus.Registry, ks string) *Metrics {
+func NewSingleMetrics(ks string) *Metrics {
 	ksLabel := strings.ReplaceAll(ks, "-", "_")
-	m := &Metrics{
+	return &Metrics{
 		CCU: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: "ab_infra_manager",
 			Name:      ksLabel,
 			Help:      fmt.Sprintf("Infra CCU setup/tier based on AccelByte's MSA doc with %s version as labels", ksLabel),
 		}, []string{"environment", "aws_account_id", "aws_region", "resource", "version", "patch", "live"}),
 	}
-	reg.MustRegister(m.CCU)
-	return m
 }
 
-func Init(reg *prometheus.Registry, ksResources []string) map[string]*Metrics {
-	m := make(map[string]*Metrics)
-	for _, ks := range ksResources {
-		met := NewSingleMetrics(reg, ks)
-		m[ks] = met
+// Register creates and registers the metrics of a Kustomization. Unlike MustRegister
+// it returns an error, since Kustomizations are discovered at runtime.
+func Register(reg *prometheus.Registry, ks string) (*Metrics, error) {
+	m := NewSingleMetrics(ks)
+	if err := reg.Register(m.CCU); err != nil {
+		return nil, err
 	}
-	return m
+	return m, nil
+}
+
+// Unregister removes the metrics of a Kustomization that is no longer tracked.
+func Unregister(reg *prometheus.Registry, m *Metrics) {
+	reg.Unregister(m.CCU)
 }
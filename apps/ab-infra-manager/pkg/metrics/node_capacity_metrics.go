@@ -0,0 +1,207 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Labels of the nodes and EC2 instances of a Karpenter NodePool or an EKS managed
+// node group. Self-managed nodes have no node pool.
+var (
+	nodePoolLabels     = []string{"karpenter.sh/nodepool", "eks.amazonaws.com/nodegroup"}
+	capacityTypeLabels = []string{"karpenter.sh/capacity-type", "eks.amazonaws.com/capacityType"}
+)
+
+// listPageSize limits the nodes and pods of a list call, so large clusters are
+// read in pages.
+const listPageSize = 500
+
+// NodeCapacityCollector reports the nodes of the cluster per node pool, with the
+// CPU and memory they can allocate and the requests of the pods running on them.
+type NodeCapacityCollector struct {
+	Clientset kubernetes.Interface
+}
+
+// nodePoolCapacity sums the nodes of a node pool.
+type nodePoolCapacity struct {
+	nodes             int
+	readyNodes        int
+	pods              int
+	allocatableCPU    resource.Quantity
+	allocatableMemory resource.Quantity
+	requestedCPU      resource.Quantity
+	requestedMemory   resource.Quantity
+}
+
+// Scrape reads the nodes and pods of the cluster, it runs in the background of a Refresher.
+func (c *NodeCapacityCollector) Scrape(ctx context.Context) ([]prometheus.Metric, error) {
+	pools := make(map[[2]string]*nodePoolCapacity)
+	nodePools := make(map[string][2]string)
+	err := listPages(ctx, func(opts metav1.ListOptions) (string, error) {
+		nodes, err := c.Clientset.CoreV1().Nodes().List(ctx, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to list nodes: %w", err)
+		}
+		for _, node := range nodes.Items {
+			pool := [2]string{labelValue(node.Labels, nodePoolLabels), capacityType(labelValue(node.Labels, capacityTypeLabels))}
+			nodePools[node.Name] = pool
+			capacity := pools[pool]
+			if capacity == nil {
+				capacity = &nodePoolCapacity{}
+				pools[pool] = capacity
+			}
+			capacity.nodes++
+			if nodeReady(node) {
+				capacity.readyNodes++
+			}
+			capacity.allocatableCPU.Add(*node.Status.Allocatable.Cpu())
+			capacity.allocatableMemory.Add(*node.Status.Allocatable.Memory())
+		}
+		return nodes.Continue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = listPages(ctx, func(opts metav1.ListOptions) (string, error) {
+		// finished pods no longer hold their requests
+		opts.FieldSelector = "status.phase!=Succeeded,status.phase!=Failed"
+		pods, err := c.Clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to list pods: %w", err)
+		}
+		for _, pod := range pods.Items {
+			pool, ok := nodePools[pod.Spec.NodeName]
+			if !ok {
+				continue
+			}
+			capacity := pools[pool]
+			capacity.pods++
+			requests := podRequests(pod)
+			capacity.requestedCPU.Add(*requests.Cpu())
+			capacity.requestedMemory.Add(*requests.Memory())
+		}
+		return pods.Continue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []prometheus.Metric
+	labels := []string{"node_pool", "capacity_type"}
+	gauge := func(name, help string, value float64, pool [2]string) {
+		metrics = append(metrics, prometheus.MustNewConstMetric(
+			prometheus.NewDesc(prometheus.BuildFQName("eks", "node_pool", name), help, labels, nil),
+			prometheus.GaugeValue,
+			value,
+			pool[0], pool[1]))
+	}
+	for pool, capacity := range pools {
+		gauge("nodes", "Nodes of a node pool", float64(capacity.nodes), pool)
+		gauge("ready_nodes", "Nodes of a node pool with the Ready condition", float64(capacity.readyNodes), pool)
+		gauge("pods", "Running and pending pods scheduled on the nodes of a node pool", float64(capacity.pods), pool)
+		gauge("allocatable_cpu_cores", "CPU of a node pool allocatable to pods", capacity.allocatableCPU.AsApproximateFloat64(), pool)
+		gauge("allocatable_memory_bytes", "Memory of a node pool allocatable to pods", capacity.allocatableMemory.AsApproximateFloat64(), pool)
+		gauge("requested_cpu_cores", "CPU requested by the pods of a node pool", capacity.requestedCPU.AsApproximateFloat64(), pool)
+		gauge("requested_memory_bytes", "Memory requested by the pods of a node pool", capacity.requestedMemory.AsApproximateFloat64(), pool)
+	}
+	return metrics, nil
+}
+
+// listPages calls list until the API returns no continue token.
+func listPages(ctx context.Context, list func(opts metav1.ListOptions) (string, error)) error {
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		next, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if next == "" || ctx.Err() != nil {
+			return ctx.Err()
+		}
+		opts.Continue = next
+	}
+}
+
+// labelValue returns the value of the first of keys in labels.
+func labelValue(labels map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// capacityType normalizes the Karpenter (on-demand, spot) and EKS (ON_DEMAND,
+// SPOT) capacity types.
+func capacityType(value string) string {
+	return strings.ReplaceAll(strings.ToLower(value), "_", "-")
+}
+
+func nodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podRequests are the requests the scheduler reserves for a pod, as in the
+// resource helpers of Kubernetes: its containers and sidecars, or if that is more,
+// an init container with the sidecars started before it, plus the pod overhead.
+func podRequests(pod corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResources(requests, container.Resources.Requests)
+	}
+
+	// init containers run one after the other, next to the sidecars started so far
+	sidecars := corev1.ResourceList{}
+	initRequests := corev1.ResourceList{}
+	for _, container := range pod.Spec.InitContainers {
+		running := corev1.ResourceList{}
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			addResources(sidecars, container.Resources.Requests)
+		} else {
+			addResources(running, container.Resources.Requests)
+		}
+		addResources(running, sidecars)
+		maxResources(initRequests, running)
+	}
+
+	// sidecars keep running next to the containers
+	addResources(requests, sidecars)
+	maxResources(requests, initRequests)
+	addResources(requests, pod.Spec.Overhead)
+	return requests
+}
+
+func addResources(total, add corev1.ResourceList) {
+	for name, quantity := range add {
+		current := total[name]
+		current.Add(quantity)
+		total[name] = current
+	}
+}
+
+// maxResources raises every resource of total to the one of other if it is more.
+func maxResources(total, other corev1.ResourceList) {
+	for name, quantity := range other {
+		if current, ok := total[name]; !ok || quantity.Cmp(current) > 0 {
+			total[name] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func container(cpu, memory string) corev1.Container {
+	return corev1.Container{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}}}
+}
+
+func sidecar(cpu, memory string) corev1.Container {
+	c := container(cpu, memory)
+	always := corev1.ContainerRestartPolicyAlways
+	c.RestartPolicy = &always
+	return c
+}
+
+func TestPodRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        corev1.PodSpec
+		cpu, memory string
+	}{
+		{
+			name:   "containers",
+			spec:   corev1.PodSpec{Containers: []corev1.Container{container("100m", "64Mi"), container("200m", "128Mi")}},
+			cpu:    "300m",
+			memory: "192Mi",
+		},
+		{
+			name: "init container larger than the containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{container("1", "32Mi")},
+				Containers:     []corev1.Container{container("100m", "64Mi")},
+			},
+			cpu:    "1",
+			memory: "64Mi",
+		},
+		{
+			name: "sidecars run next to the containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{sidecar("50m", "16Mi")},
+				Containers:     []corev1.Container{container("100m", "64Mi")},
+			},
+			cpu:    "150m",
+			memory: "80Mi",
+		},
+		{
+			name: "init container after a sidecar runs next to it",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{sidecar("200m", "16Mi"), container("500m", "32Mi")},
+				Containers:     []corev1.Container{container("100m", "64Mi")},
+			},
+			cpu:    "700m",
+			memory: "80Mi",
+		},
+		{
+			name: "init container before a sidecar runs alone",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{container("500m", "32Mi"), sidecar("200m", "16Mi")},
+				Containers:     []corev1.Container{container("100m", "64Mi")},
+			},
+			cpu:    "500m",
+			memory: "80Mi",
+		},
+		{
+			name: "overhead",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{container("100m", "64Mi")},
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("8Mi"),
+				},
+			},
+			cpu:    "110m",
+			memory: "72Mi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := podRequests(corev1.Pod{Spec: tt.spec})
+			if cpu := requests[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse(tt.cpu)) != 0 {
+				t.Errorf("cpu = %s, want %s", cpu.String(), tt.cpu)
+			}
+			if memory := requests[corev1.ResourceMemory]; memory.Cmp(resource.MustParse(tt.memory)) != 0 {
+				t.Errorf("memory = %s, want %s", memory.String(), tt.memory)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"accelbyte/ab-infra-manager/pkg/awserr"
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Scraper fetches the metrics of a source, e.g. AWS subnets or usage. Metrics are
+// kept even with an error, a source can partially fail.
+type Scraper interface {
+	Scrape(ctx context.Context) ([]prometheus.Metric, error)
+}
+
+// Refresher scrapes a source in the background and serves the last snapshot, so a
+// Prometheus scrape never waits on AWS.
+type Refresher struct {
+	// Source labels the scrape metrics, e.g. aws_subnet.
+	Source  string
+	Scraper Scraper
+	// Interval and Timeout are read before every refresh, so reloads apply.
+	Interval func() time.Duration
+	Timeout  func() time.Duration
+	Stats    *ScrapeMetrics
+	// Backoff delays refreshes while AWS throttles or denies access.
+	Backoff *awserr.Backoff
+
+	mu       sync.RWMutex
+	snapshot []prometheus.Metric
+}
+
+// refreshJitter spreads refreshes of different sources and replicas, as a
+// fraction of the interval.
+const refreshJitter = 0.1
+
+// Start refreshes right away and then every interval, plus jitter, until stop is closed.
+func (r *Refresher) Start(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	go func() {
+		for {
+			r.refresh(ctx)
+
+			interval := r.Interval()
+			interval += time.Duration(rand.Float64() * refreshJitter * float64(interval))
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+func (r *Refresher) refresh(ctx context.Context) {
+	if !r.Backoff.Allow(time.Now()) {
+		status := r.Backoff.Status()
+		log.DebugContext(ctx, "skipping refresh, backing off", "source", r.Source, "state", status.State, "retry_at", status.RetryAt)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout())
+	defer cancel()
+
+	start := time.Now()
+	metrics, err := r.Scraper.Scrape(ctx)
+	r.Stats.Duration.WithLabelValues(r.Source).Set(time.Since(start).Seconds())
+	class := r.Backoff.Observe(err, time.Now())
+	if err != nil {
+		r.Stats.Errors.WithLabelValues(r.Source, class.String()).Inc()
+		log.ErrorContext(ctx, "failed to refresh metrics", "source", r.Source, "class", class.String(), "error", err)
+	} else {
+		r.Stats.LastSuccess.WithLabelValues(r.Source).Set(float64(time.Now().Unix()))
+	}
+	state := r.Backoff.Status().State
+	for _, s := range awserr.States {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		r.Stats.State.WithLabelValues(r.Source, s).Set(value)
+	}
+	if err != nil && len(metrics) == 0 {
+		// keep serving the previous snapshot
+		return
+	}
+
+	r.mu.Lock()
+	r.snapshot = metrics
+	r.mu.Unlock()
+}
+
+// Describe sends nothing, the metrics of a source are only known once scraped.
+func (r *Refresher) Describe(ch chan<- *prometheus.Desc) {}
+
+func (r *Refresher) Collect(ch chan<- prometheus.Metric) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, metric := range r.snapshot {
+		ch <- metric
+	}
+}
+
+// ScrapeMetrics reports the background refreshes of each source.
+type ScrapeMetrics struct {
+	Duration    *prometheus.GaugeVec
+	LastSuccess *prometheus.GaugeVec
+	Errors      *prometheus.CounterVec
+	State       *prometheus.GaugeVec
+}
+
+func NewScrapeMetrics(reg *prometheus.Registry) *ScrapeMetrics {
+	m := &ScrapeMetrics{
+		Duration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "scrape",
+			Name:      "duration_seconds",
+			Help:      "Duration of the last refresh of a source",
+		}, []string{"source"}),
+		LastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "scrape",
+			Name:      "last_success_timestamp",
+			Help:      "Timestamp of the last successful refresh of a source",
+		}, []string{"source"}),
+		Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "scrape",
+			Name:      "errors_total",
+			Help:      "Number of failed refreshes of a source, by error class",
+		}, []string{"source", "class"}),
+		State: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ab_infra_manager",
+			Subsystem: "scrape",
+			Name:      "state",
+			Help:      "Current state of a source: ok, throttled, access_denied or error",
+		}, []string{"source", "state"}),
+	}
+	reg.MustRegister(m.Duration, m.LastSuccess, m.Errors, m.State)
+	return m
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AuditEntry records a change made through the write API.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// ClaimedActor is the X-Actor header as sent by the caller. It is not
+	// verified, every caller authenticates with the same API token.
+	ClaimedActor string `json:"claimedActor"`
+	Setting      string `json:"setting"`
+	Old          string `json:"old"`
+	New          string `json:"new"`
+}
@@ -11,13 +11,87 @@ import (
 type Cfg struct {
 	CCU                 int64
 	Live                bool
-	K8sClientSet        *kubernetes.Clientset
-	K8sDynamicClientSet *dynamic.DynamicClient
+	K8sClientSet        kubernetes.Interface `json:"-"`
+	K8sDynamicClientSet dynamic.Interface    `json:"-"`
 	KsResources         []string
 	KsNamespace         string
+	KsTrackKey          string
+	LegacyMetrics       bool
+	ConfigMapNamespace  string
+	ConfigMapName       string
 	LogLevel            int
+	// APIToken is read from the environment at startup, changing it needs a restart.
+	APIToken string `json:"-"`
 
 	AWSProfile             string
 	AWSUsageScrapeInterval time.Duration
 	AWSUsageTimeRange      time.Duration
+	AWSUsagePeriod         time.Duration
+	AWSUsageStatistics     []string
+
+	// background refresh of the AWS metrics, Prometheus scrapes read the last snapshot
+	AWSSubnetRefreshInterval    time.Duration
+	AWSUsageRefreshInterval     time.Duration
+	AWSComputeRefreshInterval   time.Duration
+	NodeCapacityRefreshInterval time.Duration
+	AWSScrapeTimeout            time.Duration
+	// AWSSubnetForecastWindow is the history of used subnet IPs the exhaustion
+	// forecast is based on, kept with the usage persistence.
+	AWSSubnetForecastWindow time.Duration
+
+	Recommendation RecommendationThresholds
+	Ownership      OwnershipConfig
+
+	// Persistence is read at startup only, UsageHistory is the number of usage
+	// windows it keeps.
+	Persistence  PersistenceConfig
+	UsageHistory int
+
+	// AuditConfigMapName is the ConfigMap keeping the audit log of the write API,
+	// in ConfigMapNamespace. Read at startup only.
+	AuditConfigMapName string
+}
+
+// PersistenceConfig selects where usage snapshots are kept across restarts.
+type PersistenceConfig struct {
+	// Backend is none, bolt, configmap or secret.
+	Backend string
+	// Path of the BoltDB file, on a persistent volume.
+	Path string
+	// Name of the ConfigMap or Secret, in ConfigMapNamespace.
+	Name string
+}
+
+// OwnershipConfig decides which AWS resources belong to the environment.
+type OwnershipConfig struct {
+	// RequiredTags are tag keys, valued from cluster-variables, or key=value.
+	RequiredTags []string
+	// NamePattern matches untagged resources by name, {environment} is replaced
+	// by the environment name.
+	NamePattern  string
+	NameFallback bool
+}
+
+// StandardStatistics are the CloudWatch statistics usage can be reduced to besides
+// percentiles such as p95.
+var StandardStatistics = []string{"Average", "Maximum", "Minimum"}
+
+// RecommendationThresholds are the peak utilization percentages below which an
+// instance is downsized and above which it is upsized.
+type RecommendationThresholds struct {
+	// Statistic of the usage compared to the thresholds, one of AWSUsageStatistics.
+	Statistic  string
+	CPULow     float64
+	CPUHigh    float64
+	MemoryLow  float64
+	MemoryHigh float64
+}
+
+// ReloadStatus describes the last attempt to apply the ab-infra-manager ConfigMap.
+type ReloadStatus struct {
+	ResourceVersion string    `json:"resourceVersion"`
+	LastAttempt     time.Time `json:"lastAttempt"`
+	LastSuccess     time.Time `json:"lastSuccess"`
+	Success         bool      `json:"success"`
+	Error           string    `json:"error,omitempty"`
 }
@@ -0,0 +1,8 @@
+package models
+
+// HealthCheckResult is the outcome of a single readiness check.
+type HealthCheckResult struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Kustomization is the observed state of a Flux Kustomization.
+type Kustomization struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Revision string `json:"revision"`
+	// Ready is the status of the Ready condition: True, False or Unknown.
+	Ready string `json:"ready"`
+	// LastReconcileTime is the later of the last Ready status change and the last
+	// handled reconcile request. Periodic reconciles of a healthy Kustomization do
+	// not move it.
+	LastReconcileTime time.Time `json:"lastReconcileTime"`
+
+	Labels      map[string]string `json:"-"`
+	Annotations map[string]string `json:"-"`
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+// Package ownership decides which AWS resources belong to an environment. It is
+// shared by every scraper, so RDS, DocDB, ElastiCache, Kafka and subnets follow
+// the same rules.
+package ownership
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EnvironmentPlaceholder in a name pattern is replaced by the quoted environment name.
+const EnvironmentPlaceholder = "{environment}"
+
+// Rules match a resource by its tags, or by its name when it has none of the
+// required tags.
+type Rules struct {
+	// RequiredTags must all be set to these values.
+	RequiredTags map[string]string
+	// NamePattern matches untagged resources, nil disables the fallback.
+	NamePattern *regexp.Regexp
+}
+
+// NewRules builds the rules of an environment. A required tag is either a key,
+// whose value is taken from defaults (customer_name, project, environment_name),
+// or key=value.
+func NewRules(c models.OwnershipConfig, defaults map[string]string, environment string) (*Rules, error) {
+	r := &Rules{RequiredTags: make(map[string]string)}
+	for _, tag := range c.RequiredTags {
+		key, value, explicit := strings.Cut(tag, "=")
+		if !explicit {
+			value = defaults[key]
+		}
+		if key == "" || value == "" {
+			return nil, fmt.Errorf("no value for required tag %q", tag)
+		}
+		r.RequiredTags[key] = value
+	}
+
+	if c.NameFallback {
+		pattern := strings.ReplaceAll(c.NamePattern, EnvironmentPlaceholder, regexp.QuoteMeta(environment))
+		namePattern, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %w", c.NamePattern, err)
+		}
+		r.NamePattern = namePattern
+	}
+	return r, nil
+}
+
+// Match reports whether a resource belongs to the environment. A resource carrying
+// any of the required tags is matched on its tags only, so one tagged for another
+// environment is never claimed because of its name.
+func (r *Rules) Match(tags map[string]string, name string) bool {
+	tagged := false
+	for key := range r.RequiredTags {
+		if _, ok := tags[key]; ok {
+			tagged = true
+			break
+		}
+	}
+	if tagged {
+		for key, value := range r.RequiredTags {
+			if tags[key] != value {
+				return false
+			}
+		}
+		return true
+	}
+	return r.NamePattern != nil && r.NamePattern.MatchString(name)
+}
+
+// Matcher applies Rules with the tags of every resource, as returned by a TagSource.
+type Matcher struct {
+	Rules *Rules
+	// Tags by resource ARN.
+	Tags map[string]map[string]string
+}
+
+// Owns reports whether the resource arn, named name, belongs to the environment.
+// tags can be given when the describe call already returned them, otherwise they
+// are looked up by ARN.
+func (m *Matcher) Owns(arn, name string, tags map[string]string) bool {
+	if tags == nil {
+		tags = m.Tags[arn]
+	}
+	return m.Rules.Match(tags, name)
+}
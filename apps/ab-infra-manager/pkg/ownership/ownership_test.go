@@ -0,0 +1,165 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package ownership
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"testing"
+)
+
+var defaultTags = map[string]string{
+	"customer_name":    "acme",
+	"project":          "game",
+	"environment_name": "dev",
+}
+
+const environment = "acme-game-dev"
+
+func defaultConfig() models.OwnershipConfig {
+	return models.OwnershipConfig{
+		RequiredTags: []string{"customer_name", "project", "environment_name"},
+		NamePattern:  "(^|-){environment}(-|$)",
+		NameFallback: true,
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   func(c *models.OwnershipConfig)
+		tags     map[string]string
+		resource string
+		want     bool
+	}{
+		{
+			name:     "all required tags match",
+			tags:     map[string]string{"customer_name": "acme", "project": "game", "environment_name": "dev", "team": "x"},
+			resource: "anything",
+			want:     true,
+		},
+		{
+			name:     "tagged for another environment is not matched by name",
+			tags:     map[string]string{"customer_name": "acme", "project": "game", "environment_name": "prod"},
+			resource: "acme-game-dev-redis",
+			want:     false,
+		},
+		{
+			name:     "partially tagged is matched on tags only",
+			tags:     map[string]string{"customer_name": "acme"},
+			resource: "acme-game-dev-redis",
+			want:     false,
+		},
+		{
+			name:     "untagged matches by name",
+			tags:     map[string]string{"Name": "x"},
+			resource: "acme-game-dev-redis",
+			want:     true,
+		},
+		{
+			name:     "untagged name is the environment",
+			resource: "acme-game-dev",
+			want:     true,
+		},
+		{
+			name:     "environment prefix of a longer name does not match",
+			resource: "acme-game-devtest-redis",
+			want:     false,
+		},
+		{
+			name:     "environment without dash boundaries does not match",
+			resource: "xacme-game-dev",
+			want:     false,
+		},
+		{
+			name:     "untagged without fallback is not matched",
+			config:   func(c *models.OwnershipConfig) { c.NameFallback = false },
+			resource: "acme-game-dev-redis",
+			want:     false,
+		},
+		{
+			name:     "explicit tag value",
+			config:   func(c *models.OwnershipConfig) { c.RequiredTags = []string{"owner=platform"} },
+			tags:     map[string]string{"owner": "platform"},
+			resource: "other",
+			want:     true,
+		},
+		{
+			name:     "explicit tag value mismatch",
+			config:   func(c *models.OwnershipConfig) { c.RequiredTags = []string{"owner=platform"} },
+			tags:     map[string]string{"owner": "data"},
+			resource: "acme-game-dev",
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultConfig()
+			if tt.config != nil {
+				tt.config(&c)
+			}
+			rules, err := NewRules(c, defaultTags, environment)
+			if err != nil {
+				t.Fatalf("NewRules() error = %v", err)
+			}
+			if got := rules.Match(tt.tags, tt.resource); got != tt.want {
+				t.Errorf("Match(%v, %q) = %v, want %v", tt.tags, tt.resource, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRulesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.OwnershipConfig
+	}{
+		{
+			name:   "required tag without a default value",
+			config: models.OwnershipConfig{RequiredTags: []string{"team"}},
+		},
+		{
+			name:   "required tag with an empty value",
+			config: models.OwnershipConfig{RequiredTags: []string{"team="}},
+		},
+		{
+			name:   "invalid name pattern",
+			config: models.OwnershipConfig{NamePattern: "({environment}", NameFallback: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRules(tt.config, defaultTags, environment); err == nil {
+				t.Errorf("NewRules() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestMatcherOwns(t *testing.T) {
+	rules, err := NewRules(defaultConfig(), defaultTags, environment)
+	if err != nil {
+		t.Fatalf("NewRules() error = %v", err)
+	}
+	m := &Matcher{
+		Rules: rules,
+		Tags: map[string]map[string]string{
+			"arn:aws:elasticache:us-east-1:1:cluster:ours":   defaultTags,
+			"arn:aws:elasticache:us-east-1:1:cluster:theirs": {"customer_name": "other", "project": "game", "environment_name": "dev"},
+		},
+	}
+
+	if !m.Owns("arn:aws:elasticache:us-east-1:1:cluster:ours", "ours", nil) {
+		t.Errorf("Owns() = false for a resource tagged by the Tagging API")
+	}
+	if m.Owns("arn:aws:elasticache:us-east-1:1:cluster:theirs", "acme-game-dev-theirs", nil) {
+		t.Errorf("Owns() = true for a resource tagged for another customer")
+	}
+	if !m.Owns("arn:aws:elasticache:us-east-1:1:cluster:untagged", "acme-game-dev-cache", nil) {
+		t.Errorf("Owns() = false for an untagged resource matching the name pattern")
+	}
+	if !m.Owns("arn:aws:rds:us-east-1:1:db:described", "described", defaultTags) {
+		t.Errorf("Owns() = false for tags given by the describe call")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package ownership
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+)
+
+// TagSource returns the tags of every resource of the given types, by ARN.
+type TagSource interface {
+	ResourceTags(ctx context.Context, resourceTypes []string) (map[string]map[string]string, error)
+}
+
+// TaggingAPI reads tags with the Resource Groups Tagging API, one paginated call
+// for all services instead of a ListTagsForResource per resource.
+type TaggingAPI struct {
+	client *resourcegroupstaggingapi.Client
+}
+
+func NewTaggingAPI(awsConfig aws.Config) *TaggingAPI {
+	return &TaggingAPI{client: resourcegroupstaggingapi.NewFromConfig(awsConfig)}
+}
+
+func (t *TaggingAPI) ResourceTags(ctx context.Context, resourceTypes []string) (map[string]map[string]string, error) {
+	tags := make(map[string]map[string]string)
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(t.client, &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: resourceTypes,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range page.ResourceTagMappingList {
+			resourceTags := make(map[string]string, len(resource.Tags))
+			for _, tag := range resource.Tags {
+				resourceTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			tags[aws.ToString(resource.ResourceARN)] = resourceTags
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var snapshotBucket = []byte("snapshots")
+
+// Bolt stores snapshots in a bucket of a BoltDB file, meant for a persistent volume.
+type Bolt struct {
+	db     *bolt.DB
+	bucket []byte
+	// sub backends share the file of their parent
+	sub bool
+}
+
+// OpenBolt opens or creates the BoltDB file at path.
+func OpenBolt(path string) (*Bolt, error) {
+	// the file is locked, a second pod on the same volume waits instead of corrupting it
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(snapshotBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Bolt{db: db, bucket: snapshotBucket}, nil
+}
+
+func (b *Bolt) Put(ctx context.Context, id string, snapshot []byte, keep int) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.bucket)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte(id), snapshot); err != nil {
+			return err
+		}
+
+		// keys are sorted, the oldest come first
+		var keys [][]byte
+		err = bucket.ForEach(func(k, _ []byte) error {
+			keys = append(keys, bytes.Clone(k))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys[:max(0, len(keys)-keep)] {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *Bolt) Load(ctx context.Context) ([][]byte, error) {
+	var snapshots [][]byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.bucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			// values are only valid during the transaction
+			snapshots = append(snapshots, bytes.Clone(v))
+			return nil
+		})
+	})
+	return snapshots, err
+}
+
+// Sub keeps snapshots in the bucket name of the same file.
+func (b *Bolt) Sub(name string) Backend {
+	return &Bolt{db: b.db, bucket: []byte(name), sub: true}
+}
+
+func (b *Bolt) Close() error {
+	if b.sub {
+		return nil
+	}
+	return b.db.Close()
+}
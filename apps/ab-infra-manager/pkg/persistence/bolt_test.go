@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestBolt(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "usage.db")
+	b, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := b.Sub("ip-history")
+	if history, err := sub.Load(ctx); err != nil || len(history) != 0 {
+		t.Fatalf("Load of an empty sub backend = %q, %v", history, err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if err := b.Put(ctx, id, []byte("snapshot "+id), 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := sub.Put(ctx, id, []byte("history "+id), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new pod reads both back from the file
+	b, err = OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	snapshots, err := b.Load(ctx)
+	if err != nil || len(snapshots) != 2 || string(snapshots[0]) != "snapshot 2" || string(snapshots[1]) != "snapshot 3" {
+		t.Errorf("Load = %q, %v", snapshots, err)
+	}
+	history, err := b.Sub("ip-history").Load(ctx)
+	if err != nil || len(history) != 1 || string(history[0]) != "history 3" {
+		t.Errorf("sub Load = %q, %v", history, err)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package persistence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// snapshotKeyPrefix is followed by the snapshot ID, a sub backend uses its name
+// instead.
+const snapshotKeyPrefix = "snapshot-"
+
+// maxDataSize is the data a ConfigMap or Secret can hold: objects are limited to
+// 1MiB, some room is left for the metadata.
+const maxDataSize = 1<<20 - 16<<10
+
+// Kubernetes stores gzipped snapshots in a ConfigMap or a Secret, one key each.
+// Objects are limited to 1MiB, which bounds the history of large environments.
+type Kubernetes struct {
+	clientset kubernetes.Interface
+	kind      string
+	namespace string
+	name      string
+	// prefix of the keys of the snapshots
+	prefix string
+}
+
+// NewKubernetes stores snapshots in the ConfigMap, kind configmap, or the Secret,
+// kind secret, namespace/name. The object ships with the deployment manifests, the
+// service account may only read and update it.
+func NewKubernetes(clientset kubernetes.Interface, kind, namespace, name string) *Kubernetes {
+	return &Kubernetes{clientset: clientset, kind: kind, namespace: namespace, name: name, prefix: snapshotKeyPrefix}
+}
+
+// Sub keeps snapshots in the same object, under keys prefixed with name. The
+// object size is shared, a Put drops the oldest snapshots of its own backend.
+func (k *Kubernetes) Sub(name string) Backend {
+	sub := *k
+	sub.prefix = name + "-"
+	return &sub
+}
+
+func (k *Kubernetes) Put(ctx context.Context, id string, snapshot []byte, keep int) error {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(snapshot); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	key := k.prefix + id
+	if compressed.Len() > maxDataSize {
+		return fmt.Errorf("snapshot %s is %d bytes compressed, above the %d bytes a %s can hold", id, compressed.Len(), maxDataSize, k.kind)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		data, meta, err := k.get(ctx)
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%s %s/%s does not exist, it is created by the deployment manifests: %w", k.kind, k.namespace, k.name, err)
+		}
+		if err != nil {
+			return err
+		}
+		data[key] = compressed.Bytes()
+		keys := snapshotKeys(data, k.prefix)
+		for _, old := range keys[:max(0, len(keys)-keep)] {
+			delete(data, old)
+		}
+		trimToSize(data, k.prefix, maxDataSize)
+		return k.put(ctx, data, meta)
+	})
+}
+
+// trimToSize drops the oldest snapshots with prefix of data until it holds at most
+// size bytes.
+func trimToSize(data map[string][]byte, prefix string, size int) {
+	total := 0
+	for _, value := range data {
+		total += len(value)
+	}
+	for _, old := range snapshotKeys(data, prefix) {
+		if total <= size {
+			return
+		}
+		total -= len(data[old])
+		delete(data, old)
+	}
+}
+
+func (k *Kubernetes) Load(ctx context.Context) ([][]byte, error) {
+	data, _, err := k.get(ctx)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots [][]byte
+	for _, key := range snapshotKeys(data, k.prefix) {
+		r, err := gzip.NewReader(bytes.NewReader(data[key]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		snapshot, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (k *Kubernetes) Close() error {
+	return nil
+}
+
+// get returns the data of the object and its metadata.
+func (k *Kubernetes) get(ctx context.Context) (map[string][]byte, metav1.ObjectMeta, error) {
+	var data map[string][]byte
+	var meta metav1.ObjectMeta
+	var err error
+	if k.kind == BackendSecret {
+		var secret *corev1.Secret
+		secret, err = k.clientset.CoreV1().Secrets(k.namespace).Get(ctx, k.name, metav1.GetOptions{})
+		if err == nil {
+			data, meta = secret.Data, secret.ObjectMeta
+		}
+	} else {
+		var configMap *corev1.ConfigMap
+		configMap, err = k.clientset.CoreV1().ConfigMaps(k.namespace).Get(ctx, k.name, metav1.GetOptions{})
+		if err == nil {
+			data, meta = configMap.BinaryData, configMap.ObjectMeta
+		}
+	}
+	if err != nil {
+		return nil, meta, err
+	}
+	if data == nil {
+		data = make(map[string][]byte)
+	}
+	return data, meta, nil
+}
+
+// put updates the object read with meta. Its labels and annotations are kept and a
+// concurrent write fails on the resource version, so it is retried.
+func (k *Kubernetes) put(ctx context.Context, data map[string][]byte, meta metav1.ObjectMeta) error {
+	var err error
+	if k.kind == BackendSecret {
+		secret := &corev1.Secret{ObjectMeta: meta, Data: data}
+		_, err = k.clientset.CoreV1().Secrets(k.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: meta, BinaryData: data}
+	_, err = k.clientset.CoreV1().ConfigMaps(k.namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
+
+// snapshotKeys returns the keys of data with prefix, oldest first.
+func snapshotKeys(data map[string][]byte, prefix string) []string {
+	var keys []string
+	for key := range maps.Keys(data) {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
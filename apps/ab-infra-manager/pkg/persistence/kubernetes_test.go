@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTrimToSize(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		want []string
+	}{
+		{"fits", 40, []string{"other", "snapshot-1", "snapshot-2", "snapshot-3"}},
+		{"drops the oldest", 30, []string{"other", "snapshot-2", "snapshot-3"}},
+		{"keeps the newest", 15, []string{"other", "snapshot-3"}},
+		{"other keys are kept", 0, []string{"other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string][]byte{
+				"snapshot-1": []byte(strings.Repeat("a", 10)),
+				"snapshot-2": []byte(strings.Repeat("b", 10)),
+				"snapshot-3": []byte(strings.Repeat("c", 10)),
+				"other":      []byte(strings.Repeat("d", 5)),
+			}
+			trimToSize(data, snapshotKeyPrefix, tt.size)
+			if got := slices.Sorted(maps.Keys(data)); !slices.Equal(got, tt.want) {
+				t.Errorf("trimToSize(%d) kept %v, want %v", tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesPut(t *testing.T) {
+	ctx := context.Background()
+	usage := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:        "ab-infra-manager-usage",
+		Namespace:   "justice",
+		Annotations: map[string]string{"kustomize.toolkit.fluxcd.io/ssa": "IfNotPresent"},
+	}}
+	clientset := fake.NewSimpleClientset(usage)
+
+	missing := NewKubernetes(clientset, BackendSecret, "justice", "ab-infra-manager-usage")
+	if err := missing.Put(ctx, "1", []byte("a"), 2); !apierrors.IsNotFound(err) {
+		t.Errorf("Put to a missing secret = %v, want not found", err)
+	}
+
+	k := NewKubernetes(clientset, BackendConfigMap, "justice", "ab-infra-manager-usage")
+	for _, id := range []string{"1", "2", "3"} {
+		if err := k.Put(ctx, id, []byte("snapshot "+id), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a sub backend keeps its own snapshots in the same object
+	sub := k.Sub("ip-history")
+	for _, id := range []string{"4", "5"} {
+		if err := sub.Put(ctx, id, []byte("history "+id), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if history, err := sub.Load(ctx); err != nil || len(history) != 1 || string(history[0]) != "history 5" {
+		t.Errorf("sub Load = %q, %v", history, err)
+	}
+	snapshots, err := k.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || string(snapshots[0]) != "snapshot 2" || string(snapshots[1]) != "snapshot 3" {
+		t.Errorf("Load = %q", snapshots)
+	}
+	cm, err := clientset.CoreV1().ConfigMaps("justice").Get(ctx, "ab-infra-manager-usage", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Annotations["kustomize.toolkit.fluxcd.io/ssa"] != "IfNotPresent" {
+		t.Errorf("annotations = %v, the manifest ones were dropped", cm.Annotations)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+// Package persistence keeps a rolling history of usage snapshots, so a restart
+// does not rescrape AWS and the trend of previous windows is kept.
+package persistence
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"context"
+)
+
+// Backend stores encoded snapshots by ID, IDs sort from the oldest to the newest.
+type Backend interface {
+	// Put stores or replaces the snapshot id, and drops the oldest ones beyond keep.
+	Put(ctx context.Context, id string, snapshot []byte, keep int) error
+	// Load returns the stored snapshots, oldest first.
+	Load(ctx context.Context) ([][]byte, error)
+	// Sub returns a backend for other snapshots kept in the same place, e.g. a
+	// bucket of the same file. Its IDs and keep count apart from these ones.
+	Sub(name string) Backend
+	// Close releases the backend, a sub backend is released with its parent.
+	Close() error
+}
+
+const (
+	BackendNone      = "none"
+	BackendBolt      = "bolt"
+	BackendConfigMap = "configmap"
+	BackendSecret    = "secret"
+)
+
+// Backends are the values of USAGE_PERSISTENCE.
+var Backends = []string{BackendNone, BackendBolt, BackendConfigMap, BackendSecret}
+
+// New opens the backend selected in c, it returns nil when persistence is disabled.
+func New(c models.Cfg) (Backend, error) {
+	switch c.Persistence.Backend {
+	case BackendBolt:
+		b, err := OpenBolt(c.Persistence.Path)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case BackendConfigMap, BackendSecret:
+		return NewKubernetes(c.K8sClientSet, c.Persistence.Backend, c.ConfigMapNamespace, c.Persistence.Name), nil
+	}
+	return nil, nil
+}
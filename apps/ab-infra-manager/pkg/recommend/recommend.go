@@ -0,0 +1,115 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package recommend
+
+import (
+	"accelbyte/ab-infra-manager/pkg/instanceclass"
+	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/usagestore"
+	"fmt"
+)
+
+// Actions proposed for an instance.
+const (
+	Downsize = "downsize"
+	Keep     = "keep"
+	Upsize   = "upsize"
+)
+
+// Recommendation proposes an instance class for an instance, based on its usage.
+type Recommendation struct {
+	Identifier       string `json:"identifier"`
+	Engine           string `json:"engine"`
+	Level            string `json:"level"`
+	InstanceClass    string `json:"instanceClass"`
+	RecommendedClass string `json:"recommendedClass"`
+	Action           string `json:"action"`
+	Reason           string `json:"reason"`
+	// Statistic of the usage the recommendation is based on, e.g. Maximum or p95.
+	Statistic string   `json:"statistic"`
+	CPU       float64  `json:"cpu"`
+	Memory    *float64 `json:"memory,omitempty"`
+}
+
+// Direction is the value exported for the action: -1 downsize, 0 keep, 1 upsize.
+func (r Recommendation) Direction() float64 {
+	switch r.Action {
+	case Downsize:
+		return -1
+	case Upsize:
+		return 1
+	}
+	return 0
+}
+
+// Recommend proposes a size for every instance with a known class and CPU usage.
+// Kafka brokers are skipped, their cluster carries the peak across brokers. An
+// instance is only downsized when its memory usage is known too.
+func Recommend(usages []usagestore.Usage, t models.RecommendationThresholds) []Recommendation {
+	recommendations := []Recommendation{}
+	for _, usage := range usages {
+		cpu, ok := usage.CPU[t.Statistic]
+		if !ok || usage.Level == "Broker" {
+			continue
+		}
+		class, ok := instanceclass.Parse(usage.InstanceClass)
+		if !ok {
+			continue
+		}
+		r := Recommendation{
+			Identifier:       usage.Identifier,
+			Engine:           usage.Engine,
+			Level:            usage.Level,
+			InstanceClass:    usage.InstanceClass,
+			RecommendedClass: usage.InstanceClass,
+			Action:           Keep,
+			Statistic:        t.Statistic,
+			CPU:              cpu,
+		}
+		memory := -1.0
+		if value, ok := usage.Memory[t.Statistic]; ok {
+			memory = value
+			r.Memory = &value
+		}
+
+		switch {
+		case cpu > t.CPUHigh || memory > t.MemoryHigh:
+			larger, ok := class.Larger()
+			if !ok {
+				r.Reason = "usage above the upsize threshold, already the largest size"
+				break
+			}
+			r.Action = Upsize
+			r.RecommendedClass = larger.String()
+			r.Reason = fmt.Sprintf("%s CPU %.1f%% above the upsize threshold", t.Statistic, cpu)
+			if cpu <= t.CPUHigh {
+				r.Reason = fmt.Sprintf("%s memory %.1f%% above the upsize threshold", t.Statistic, memory)
+			}
+		case cpu < t.CPULow && memory < t.MemoryLow:
+			smaller, ok := class.Smaller()
+			if !ok {
+				r.Reason = "usage below the downsize threshold, already the smallest size"
+				break
+			}
+			if r.Memory == nil {
+				r.Reason = fmt.Sprintf("%s CPU %.1f%% below the downsize threshold, memory usage is unknown", t.Statistic, cpu)
+				break
+			}
+			// the peak grows as the capacity shrinks, e.g. doubles one size down
+			ratio := class.Capacity() / smaller.Capacity()
+			if cpu*ratio > t.CPUHigh || memory*ratio > t.MemoryHigh {
+				r.Reason = "usage after a downsize would be above the upsize threshold"
+				break
+			}
+			r.Action = Downsize
+			r.RecommendedClass = smaller.String()
+			r.Reason = fmt.Sprintf("%s CPU %.1f%% below the downsize threshold", t.Statistic, cpu)
+		default:
+			r.Reason = "usage within thresholds"
+		}
+		recommendations = append(recommendations, r)
+	}
+	return recommendations
+}
@@ -0,0 +1,72 @@
+package recommend
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/usagestore"
+	"testing"
+)
+
+func TestRecommend(t *testing.T) {
+	// a downsize doubling 35% memory or more would reach the upsize threshold
+	thresholds := models.RecommendationThresholds{Statistic: "p95", CPULow: 30, CPUHigh: 80, MemoryLow: 40, MemoryHigh: 70}
+	usage := func(class string, cpu float64, memory ...float64) usagestore.Usage {
+		u := usagestore.Usage{
+			InstanceInfo: usagestore.InstanceInfo{Identifier: "id", InstanceClass: class, Level: "Instance"},
+			CPU:          map[string]float64{"p95": cpu},
+		}
+		if len(memory) > 0 {
+			u.Memory = map[string]float64{"p95": memory[0]}
+		}
+		return u
+	}
+
+	tests := []struct {
+		name   string
+		usage  usagestore.Usage
+		action string
+		class  string
+	}{
+		{"downsize with memory", usage("cache.r6g.xlarge", 10, 20), Downsize, "cache.r6g.large"},
+		{"downsize idle database", usage("db.r6g.xlarge", 5, 10), Downsize, "db.r6g.large"},
+		{"no downsize without memory", usage("db.r6g.xlarge", 10), Keep, "db.r6g.xlarge"},
+		{"downsize off the ladder", usage("db.m5.3xlarge", 10, 20), Downsize, "db.m5.2xlarge"},
+		{"upsize off the ladder", usage("db.m5.3xlarge", 90, 20), Upsize, "db.m5.4xlarge"},
+		{"downsize from 32xlarge", usage("db.r7i.32xlarge", 10, 20), Downsize, "db.r7i.24xlarge"},
+		{"upsize to 48xlarge", usage("db.r7i.32xlarge", 90, 20), Upsize, "db.r7i.48xlarge"},
+		{"downsize to nano", usage("cache.t4g.micro", 10, 20), Downsize, "cache.t4g.nano"},
+		{"smallest standard size", usage("cache.r6g.large", 10, 20), Keep, "cache.r6g.large"},
+		{"smallest burstable size", usage("cache.t4g.nano", 10, 20), Keep, "cache.t4g.nano"},
+		{"downsize would overload", usage("cache.r6g.xlarge", 10, 39), Keep, "cache.r6g.xlarge"},
+		{"upsize on cpu without memory", usage("db.r6g.large", 90), Upsize, "db.r6g.xlarge"},
+		{"upsize on memory", usage("cache.t4g.medium", 50, 90), Upsize, "cache.t4g.large"},
+		{"largest standard size", usage("db.r7i.48xlarge", 90), Keep, "db.r7i.48xlarge"},
+		{"largest burstable size", usage("cache.t4g.2xlarge", 90, 50), Keep, "cache.t4g.2xlarge"},
+		{"opensearch upsize", usage("r6g.large.search", 90, 50), Upsize, "r6g.xlarge.search"},
+		{"opensearch downsize", usage("m6g.2xlarge.search", 10, 20), Downsize, "m6g.xlarge.search"},
+		{"within thresholds", usage("db.r6g.xlarge", 50, 50), Keep, "db.r6g.xlarge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommendations := Recommend([]usagestore.Usage{tt.usage}, thresholds)
+			if len(recommendations) != 1 {
+				t.Fatalf("Recommend() = %+v, want one recommendation", recommendations)
+			}
+			r := recommendations[0]
+			if r.Action != tt.action || r.RecommendedClass != tt.class {
+				t.Errorf("Recommend() = %s %s (%s), want %s %s", r.Action, r.RecommendedClass, r.Reason, tt.action, tt.class)
+			}
+		})
+	}
+}
+
+func TestRecommendSkips(t *testing.T) {
+	thresholds := models.RecommendationThresholds{Statistic: "p95", CPULow: 30, CPUHigh: 80, MemoryLow: 40, MemoryHigh: 85}
+	usages := []usagestore.Usage{
+		{InstanceInfo: usagestore.InstanceInfo{InstanceClass: "kafka.m5.large", Level: "Broker"}, CPU: map[string]float64{"p95": 90}},
+		{InstanceInfo: usagestore.InstanceInfo{InstanceClass: "db.serverless", Level: "Instance"}, CPU: map[string]float64{"p95": 90}},
+		{InstanceInfo: usagestore.InstanceInfo{InstanceClass: "db.r6g.large", Level: "Instance"}, CPU: map[string]float64{"Maximum": 90}},
+	}
+	if got := Recommend(usages, thresholds); len(got) != 0 {
+		t.Errorf("Recommend() = %+v, want none", got)
+	}
+}
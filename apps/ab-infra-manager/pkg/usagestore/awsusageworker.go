@@ -7,386 +7,785 @@ package usagestore
 import (
 	"accelbyte/ab-infra-manager/pkg/cache"
 	"accelbyte/ab-infra-manager/pkg/models"
+	"accelbyte/ab-infra-manager/pkg/ownership"
+	"accelbyte/ab-infra-manager/pkg/persistence"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/aws/aws-sdk-go-v2/service/docdb"
 	"github.com/aws/aws-sdk-go-v2/service/elasticache"
 	"github.com/aws/aws-sdk-go-v2/service/kafka"
+	"github.com/aws/aws-sdk-go-v2/service/opensearch"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 )
 
 var log = slog.Default()
 
 type InstanceInfo struct {
-	Identifier          string
-	InstanceClass       string
-	Engine              string
-	Level               string
-	IdentifierFieldName string
-	BrokerID            string
-	BrokerCount         int // specific for Kafka Broker
-	CollectedTime       time.Time
+	Identifier          string    `json:"identifier"`
+	InstanceClass       string    `json:"instanceClass"`
+	Engine              string    `json:"engine"`
+	Level               string    `json:"level"`
+	IdentifierFieldName string    `json:"identifierFieldName"`
+	BrokerID            string    `json:"brokerId,omitempty"`
+	BrokerCount         int       `json:"brokerCount,omitempty"` // specific for Kafka Broker
+	AccountID           string    `json:"accountId,omitempty"`   // specific for OpenSearch, the ClientId dimension
+	StorageSize         int       `json:"storageSize,omitempty"` // specific for OpenSearch, EBS GiB per data node
+	CollectedTime       time.Time `json:"collectedTime"`
 }
 
+// Usage is the utilization of an instance over the configured time range.
+type Usage struct {
+	InstanceInfo
+	// Key is the identifier, suffixed with the broker ID for Kafka brokers.
+	Key string `json:"key"`
+	// CPU and Memory hold each configured statistic, e.g. Maximum, Average or p95.
+	CPU         map[string]float64 `json:"cpu,omitempty"`
+	Memory      map[string]float64 `json:"memory,omitempty"`
+	MaxCPU      *float64           `json:"maxCpu,omitempty"`
+	MaxMemory   *float64           `json:"maxMemory,omitempty"`
+	WindowStart time.Time          `json:"windowStart"`
+	WindowEnd   time.Time          `json:"windowEnd"`
+	Error       string             `json:"error,omitempty"`
+	// Series holds the other usage of an engine by name, e.g. storage for OpenSearch
+	// or capacity_acu for Aurora Serverless.
+	Series map[string]map[string]float64 `json:"series,omitempty"`
+}
+
+// instancesKey caches every discovered instance as one item, so they expire and
+// are discovered together.
+const instancesKey = "instances"
+
+// The CloudWatch values of very large accounts are bounded, the least recently
+// used are fetched again.
+const (
+	maxCachedMetrics     = 50000
+	cacheJanitorInterval = 10 * time.Minute
+)
+
+// Retries of throttled CloudWatch calls, with exponential backoff and jitter.
+const (
+	cloudwatchMaxAttempts = 8
+	cloudwatchMaxBackoff  = 30 * time.Second
+)
+
 type UsageStore struct {
 	EnvironmentName string
 	AWSTags         map[string]string
 
-	config      *models.Cfg
-	infoStore   *cache.Cache[string, InstanceInfo]
-	metricStore *cache.Cache[string, float64]
+	config      func() *models.Cfg
+	tagSource   ownership.TagSource
+	infoStore   *cache.Cache[string, []InstanceInfo]
+	discoveryMu sync.Mutex
+	discovery   map[string]Discovery
+	metricStore *cache.Cache[string, map[string]float64]
+	historyMu   sync.Mutex
+	history     []Snapshot
+	persistence persistence.Backend
 
 	cloudwatchClient  *cloudwatch.Client
 	rdsClient         *rds.Client
 	docdbClient       *docdb.Client
 	elasticacheClient *elasticache.Client
 	kafkaClient       *kafka.Client
+	opensearchClient  *opensearch.Client
 }
 
-func New(cfg models.Cfg, awsConfig aws.Config, awsTags map[string]string, environmentName string) *UsageStore {
+// New creates a usage store. cfg returns the configuration in effect, so reloaded
+// scrape intervals and time ranges are used on the next refresh.
+func New(cfg func() *models.Cfg, awsConfig aws.Config, awsTags map[string]string, environmentName string, cacheMetrics *cache.Metrics) *UsageStore {
 	return &UsageStore{
-		config:            &cfg,
-		AWSTags:           awsTags,
-		EnvironmentName:   environmentName,
-		infoStore:         cache.New[string, InstanceInfo](),
-		metricStore:       cache.New[string, float64](),
-		cloudwatchClient:  cloudwatch.NewFromConfig(awsConfig),
+		config:          cfg,
+		AWSTags:         awsTags,
+		EnvironmentName: environmentName,
+		tagSource:       ownership.NewTaggingAPI(awsConfig),
+		infoStore:       cache.New[string, []InstanceInfo](cache.Options{Name: "aws_instances", Metrics: cacheMetrics}),
+		discovery:       make(map[string]Discovery),
+		metricStore: cache.New[string, map[string]float64](cache.Options{
+			Name:            "aws_usage_metrics",
+			MaxEntries:      maxCachedMetrics,
+			JanitorInterval: cacheJanitorInterval,
+			Metrics:         cacheMetrics,
+		}),
+		cloudwatchClient: cloudwatch.NewFromConfig(awsConfig, func(o *cloudwatch.Options) {
+			// large environments get throttled, back off instead of failing the scrape
+			o.Retryer = retry.NewStandard(func(so *retry.StandardOptions) {
+				so.MaxAttempts = cloudwatchMaxAttempts
+				so.MaxBackoff = cloudwatchMaxBackoff
+				so.RateLimiter = ratelimit.None
+			})
+		}),
 		rdsClient:         rds.NewFromConfig(awsConfig),
 		docdbClient:       docdb.NewFromConfig(awsConfig),
 		elasticacheClient: elasticache.NewFromConfig(awsConfig),
 		kafkaClient:       kafka.NewFromConfig(awsConfig),
+		opensearchClient:  opensearch.NewFromConfig(awsConfig),
+	}
+}
+
+// Reset drops every cached instance and metric, e.g. after the scrape interval or
+// time range changed.
+func (u *UsageStore) Reset() {
+	u.infoStore.Clear()
+	u.metricStore.Clear()
+}
+
+// ownedResourceTypes are the Tagging API resource types of the scraped services,
+// DocDB resources are rds:db and rds:cluster.
+var ownedResourceTypes = []string{"rds:db", "rds:cluster", "elasticache:cluster", "elasticache:serverlesscache", "kafka", "es:domain"}
+
+// owner loads the tags of every resource once per discovery, so all services are
+// matched with the same rules.
+func (u *UsageStore) owner(ctx context.Context) (*ownership.Matcher, error) {
+	rules, err := ownership.NewRules(u.config().Ownership, u.AWSTags, u.EnvironmentName)
+	if err != nil {
+		return nil, err
 	}
+	tags, err := u.tagSource.ResourceTags(ctx, ownedResourceTypes)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get resource tags", "error", err)
+		return nil, err
+	}
+	return &ownership.Matcher{Rules: rules, Tags: tags}, nil
 }
 
+// GetInstances returns the instances of the environment, discovered again once
+// the scrape interval expired. Concurrent calls share a single discovery.
 func (u *UsageStore) GetInstances(ctx context.Context) ([]InstanceInfo, error) {
-	var err error
-	log.DebugContext(ctx, "info store", "len", u.infoStore.Length(), "expired", u.infoStore.IsExpired())
-	if u.infoStore.Length() == 0 || u.infoStore.IsExpired() {
-		err = u.scrapeRDSInfo(ctx)
-		if err != nil {
-			return nil, err
+	return u.infoStore.GetOrLoad(instancesKey, u.config().AWSUsageScrapeInterval, func() ([]InstanceInfo, error) {
+		return u.discover(ctx)
+	})
+}
+
+func (u *UsageStore) discover(ctx context.Context) ([]InstanceInfo, error) {
+	owner, err := u.owner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]InstanceInfo)
+	err = u.scrapeRDSInfo(ctx, owner, found)
+	if err != nil {
+		return nil, err
+	}
+	err = u.scrapeDocDBInfo(ctx, owner, found)
+	if err != nil {
+		return nil, err
+	}
+	err = u.scrapeElastiCacheInfo(ctx, owner, found)
+	if err != nil {
+		return nil, err
+	}
+	err = u.scrapeKafkaInfo(ctx, owner, found)
+	if err != nil {
+		return nil, err
+	}
+	err = u.scrapeOpenSearchInfo(ctx, owner, found)
+	if err != nil {
+		return nil, err
+	}
+	log.DebugContext(ctx, "discovered instances", "count", len(found))
+	return slices.SortedFunc(maps.Values(found), func(a, b InstanceInfo) int {
+		return strings.Compare(usageKey(a), usageKey(b))
+	}), nil
+}
+
+// GetUsage returns the configured statistics of every usage series of every
+// instance: CPU, memory, and the Series of the engine. Instances whose metrics
+// could not be fetched are returned with Error set, the returned error joins all
+// of them.
+func (u *UsageStore) GetUsage(ctx context.Context) ([]Usage, error) {
+	instances, err := u.GetInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// everything that is not cached is fetched in as few GetMetricData calls as possible
+	var requests []metricRequest
+	pending := make(map[string]bool)
+	for _, instance := range instances {
+		for _, request := range u.metricRequests(instance) {
+			if pending[request.key] {
+				continue
+			}
+			if _, ok := u.metricStore.Get(request.key); ok {
+				continue
+			}
+			pending[request.key] = true
+			requests = append(requests, request)
 		}
-		err = u.scrapeDocDBInfo(ctx)
-		if err != nil {
-			return nil, err
+	}
+	failed := u.fetchMetrics(ctx, requests)
+
+	var errs error
+	usages := make([]Usage, 0, len(instances))
+	for _, instance := range instances {
+		usage := Usage{
+			InstanceInfo: instance,
+			Key:          usageKey(instance),
+			WindowStart:  instance.CollectedTime.Add(-u.config().AWSUsageTimeRange),
+			WindowEnd:    instance.CollectedTime,
 		}
-		err = u.scrapeElastiCacheInfo(ctx)
-		if err != nil {
-			return nil, err
+
+		var instanceErr error
+		series := instanceSeries(u.metricRequests(instance))
+		if len(series) == 0 {
+			instanceErr = fmt.Errorf("no usage metrics for engine %s", instance.Engine)
 		}
-		err = u.scrapeKafkaInfo(ctx)
-		if err != nil {
-			return nil, err
+		for _, name := range series {
+			values, err := u.seriesUsage(instance, name, failed)
+			if err != nil {
+				instanceErr = errors.Join(instanceErr, err)
+				continue
+			}
+			switch name {
+			case seriesCPU:
+				usage.CPU = values
+				if maxCPU, ok := values["Maximum"]; ok {
+					usage.MaxCPU = &maxCPU
+				}
+			case seriesMemory:
+				usage.Memory = values
+				if maxMemory, ok := values["Maximum"]; ok {
+					usage.MaxMemory = &maxMemory
+				}
+			default:
+				if usage.Series == nil {
+					usage.Series = make(map[string]map[string]float64)
+				}
+				usage.Series[name] = values
+			}
 		}
+		if instanceErr != nil {
+			logUsageError(ctx, instance, instanceErr)
+			usage.Error = instanceErr.Error()
+			errs = errors.Join(errs, instanceErr)
+		}
+		usages = append(usages, usage)
+	}
+	slices.SortFunc(usages, func(a, b Usage) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	// a window is recorded once, when its metrics were fetched
+	if len(requests) > 0 {
+		u.saveSnapshot(ctx, instances, usages)
 	}
-	instances := make([]InstanceInfo, 0)
+	return usages, errs
+}
 
-	for _, k := range u.infoStore.Keys() {
-		instance, ok := u.infoStore.Get(k)
-		if !ok {
-			continue
-		}
-		instances = append(instances, instance)
+func logUsageError(ctx context.Context, instance InstanceInfo, err error) {
+	log.ErrorContext(ctx, "failed to get db usage",
+		"error", err,
+		"identifier", instance.Identifier,
+		"engine", instance.Engine,
+		"level", instance.Level,
+		"identifier_field_name", instance.IdentifierFieldName,
+		"instance_class", instance.InstanceClass,
+	)
+}
+
+// usageKey is the identifier, suffixed with the broker ID for Kafka brokers.
+func usageKey(instance InstanceInfo) string {
+	if instance.Level == "Broker" {
+		return instance.Identifier + "-" + instance.BrokerID
 	}
-	return instances, nil
+	return instance.Identifier
 }
 
-func (u *UsageStore) metricStoreGetter(ctx context.Context, key string, metricInput *cloudwatch.GetMetricStatisticsInput) func(string) (float64, error) {
-	if _, ok := u.metricStore.Get(key); ok {
-		return func(s string) (float64, error) {
-			val, _ := u.metricStore.Get(s)
-			return val, nil
-		}
+// seriesUsage reads a usage series of an instance from the metric store. A Kafka
+// cluster reports the highest value of its brokers.
+func (u *UsageStore) seriesUsage(instance InstanceInfo, series string, failed map[string]error) (map[string]float64, error) {
+	if instance.Engine != "kafka" || instance.Level != "Cluster" {
+		return u.cachedUsage(seriesKey(instance, series), failed)
 	}
-	return func(s string) (float64, error) {
-		val, err := u.getCWMetricsMax(ctx, metricInput)
+	usage := make(map[string]float64)
+	var errs error
+	for _, broker := range kafkaBrokers(instance) {
+		brokerUsage, err := u.cachedUsage(seriesKey(broker, series), failed)
 		if err != nil {
-			log.ErrorContext(ctx, "failed to scrape cloudwatch metrics", "error", err)
-			return 0, err
+			errs = errors.Join(errs, err)
+			continue
+		}
+		for statistic, value := range brokerUsage {
+			usage[statistic] = math.Max(usage[statistic], value)
 		}
-		u.metricStore.Set(s, val, u.config.AWSUsageScrapeInterval)
-		return val, nil
 	}
+	return usage, errs
 }
 
-func (u *UsageStore) kafkaCombinedMetrics(ctx context.Context, key, brokerID string, metricInput *cloudwatch.GetMetricStatisticsInput) func(string) (float64, error) {
-	if _, ok := u.metricStore.Get(key); ok {
-		return func(s string) (float64, error) {
-			val, _ := u.metricStore.Get(s)
-			return val, nil
+func (u *UsageStore) cachedUsage(key string, failed map[string]error) (map[string]float64, error) {
+	if usage, ok := u.metricStore.Get(key); ok {
+		return usage, nil
+	}
+	if err, ok := failed[key]; ok {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no cloudwatch metrics for %s", key)
+}
+
+// fetchMetrics requests every metric with GetMetricData and stores the result of
+// each request under its key. GetMetricData takes one time range per call, so
+// requests are grouped by range and then split into batches of at most
+// maxMetricDataQueries. The keys of failed batches are returned with their error.
+func (u *UsageStore) fetchMetrics(ctx context.Context, requests []metricRequest) map[string]error {
+	failed := make(map[string]error)
+
+	type window struct{ start, end time.Time }
+	windows := make(map[window][]metricRequest)
+	for _, request := range requests {
+		w := window{request.start, request.end}
+		windows[w] = append(windows[w], request)
+	}
+
+	for w, requests := range windows {
+		var batch []metricRequest
+		queries := 0
+		for i, request := range requests {
+			batch = append(batch, request)
+			queries += u.queryCount(request)
+			if i+1 < len(requests) && queries+u.queryCount(requests[i+1]) <= maxMetricDataQueries {
+				continue
+			}
+			if err := u.fetchMetricBatch(ctx, w.start, w.end, batch); err != nil {
+				log.ErrorContext(ctx, "failed to scrape cloudwatch metrics", "error", err, "metrics", len(batch))
+				for _, request := range batch {
+					failed[request.key] = err
+				}
+			}
+			batch, queries = nil, 0
 		}
 	}
-	return func(s string) (float64, error) {
-		var err error
-		if brokerID == "" {
-			keySplit := strings.Split(s, "-")
-			brokerID = keySplit[len(keySplit)-1]
+	return failed
+}
+
+// maxMetricDataQueries is the limit of queries in a single GetMetricData call.
+const maxMetricDataQueries = 500
+
+// queryCount is the number of GetMetricData queries of a request: one per statistic
+// and metric, plus the expression adding the metrics up.
+func (u *UsageStore) queryCount(request metricRequest) int {
+	perStatistic := len(request.metricNames)
+	if perStatistic > 1 {
+		perStatistic++
+	}
+	return perStatistic * len(request.statistics(u.config().AWSUsageStatistics))
+}
+
+func (u *UsageStore) fetchMetricBatch(ctx context.Context, start, end time.Time, batch []metricRequest) error {
+	cfg := u.config()
+	period := aws.Int32(int32(cfg.AWSUsagePeriod.Seconds()))
+
+	var queries []types.MetricDataQuery
+	for i, request := range batch {
+		for j, statistic := range request.statistics(cfg.AWSUsageStatistics) {
+			id := fmt.Sprintf("q%d_%d", i, j)
+			if len(request.metricNames) == 1 {
+				queries = append(queries, metricStatQuery(id, request, request.metricNames[0], request.stat(statistic), period, true))
+				continue
+			}
+			terms := make([]string, 0, len(request.metricNames))
+			for k, metricName := range request.metricNames {
+				termID := fmt.Sprintf("%s_%d", id, k)
+				terms = append(terms, termID)
+				queries = append(queries, metricStatQuery(termID, request, metricName, request.stat(statistic), period, false))
+			}
+			queries = append(queries, types.MetricDataQuery{
+				Id:         aws.String(id),
+				Expression: aws.String(strings.Join(terms, "+")),
+				ReturnData: aws.Bool(true),
+			})
 		}
-		metricInput.Dimensions = append(metricInput.Dimensions, types.Dimension{
-			Name: aws.String("Broker ID"), Value: aws.String(brokerID),
-		})
-		metricInput.MetricName = aws.String("CpuSystem")
-		maxCpuSystem, err := u.getCWMetricsMax(ctx, metricInput)
+	}
+
+	values := make(map[string][]float64)
+	paginator := cloudwatch.NewGetMetricDataPaginator(u.cloudwatchClient, &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(start),
+		EndTime:           aws.Time(end),
+		MetricDataQueries: queries,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			log.ErrorContext(ctx, "failed to scrape cloudwatch metrics", "error", err)
-			return 0, err
+			return err
 		}
-		metricInput.MetricName = aws.String("CpuUser")
-		maxCpuUser, err := u.getCWMetricsMax(ctx, metricInput)
-		if err != nil {
-			log.ErrorContext(ctx, "failed to scrape cloudwatch metrics", "error", err)
-			return 0, err
+		for _, result := range output.MetricDataResults {
+			id := aws.ToString(result.Id)
+			values[id] = append(values[id], result.Values...)
 		}
-		u.metricStore.Set(s, maxCpuSystem+maxCpuUser, u.config.AWSUsageScrapeInterval)
-		return maxCpuSystem + maxCpuUser, nil
 	}
-}
 
-func (u *UsageStore) GetMaxCPUUsage(ctx context.Context, key string, instance InstanceInfo) (float64, error) {
-	metricInput := &cloudwatch.GetMetricStatisticsInput{
-		Dimensions: []types.Dimension{{Name: aws.String(instance.IdentifierFieldName), Value: aws.String(instance.Identifier)}},
-		EndTime:    aws.Time(instance.CollectedTime),
-		StartTime:  aws.Time(instance.CollectedTime.Add(-u.config.AWSUsageTimeRange)),
-		MetricName: aws.String("CPUUtilization"),
-		Period:     aws.Int32(3600),
-		Statistics: []types.Statistic{"Maximum"},
-	}
-
-	getMaxCPU := u.metricStoreGetter(ctx, key, metricInput)
-
-	switch instance.Engine {
-	case "postgres", "aurora-postgresql":
-		metricInput.Namespace = aws.String("AWS/RDS")
-	case "docdb":
-		metricInput.Namespace = aws.String("AWS/DocDB")
-	case "redis", "valkey":
-		metricInput.Namespace = aws.String("AWS/ElastiCache")
-	case "kafka":
-		metricInput.Namespace = aws.String("AWS/Kafka")
-		getMaxCPU = u.kafkaCombinedMetrics(ctx, key, instance.BrokerID, metricInput)
-		if instance.Level == "Cluster" {
-			var maxCPU, brokerMaxCPU float64
-			var errs error
-			var err error
-			for i := 1; i <= instance.BrokerCount; i++ {
-				brokerKey := fmt.Sprintf("%s-%d", instance.Identifier, i)
-				getMaxCPU = u.kafkaCombinedMetrics(ctx, brokerKey, fmt.Sprint(i), metricInput)
-				brokerMaxCPU, err = getMaxCPU(brokerKey)
-				if err != nil {
-					errs = errors.Join(errs, err)
-					continue
-				}
-				maxCPU = math.Max(maxCPU, brokerMaxCPU)
-			}
-			return maxCPU, errs
+	for i, request := range batch {
+		statistics := request.statistics(cfg.AWSUsageStatistics)
+		usage := make(map[string]float64, len(statistics))
+		for j, statistic := range statistics {
+			usage[statistic] = aggregate(statistic, request.convert(values[fmt.Sprintf("q%d_%d", i, j)]))
 		}
-	default:
-		metricInput.Namespace = aws.String("")
+		u.metricStore.Set(request.key, usage, cfg.AWSUsageScrapeInterval)
 	}
-	return getMaxCPU(key)
+	return nil
 }
 
-func (u *UsageStore) GetElastiCacheMaxMemoryUsage(ctx context.Context, key string, instance InstanceInfo) (float64, error) {
-	metricInput := &cloudwatch.GetMetricStatisticsInput{
-		Dimensions: []types.Dimension{{Name: aws.String(instance.IdentifierFieldName), Value: aws.String(instance.Identifier)}},
-		EndTime:    aws.Time(instance.CollectedTime),
-		StartTime:  aws.Time(instance.CollectedTime.Add(-u.config.AWSUsageTimeRange)),
-		MetricName: aws.String("DatabaseMemoryUsagePercentage"),
-		Period:     aws.Int32(3600),
-		Statistics: []types.Statistic{"Maximum"},
-	}
-	getMaxMemory := u.metricStoreGetter(ctx, key, metricInput)
-	return getMaxMemory(key)
+func metricStatQuery(id string, request metricRequest, metricName, statistic string, period *int32, returnData bool) types.MetricDataQuery {
+	return types.MetricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &types.MetricStat{
+			Metric: &types.Metric{
+				Namespace:  aws.String(request.namespace),
+				MetricName: aws.String(metricName),
+				Dimensions: request.dimensions,
+			},
+			Period: period,
+			Stat:   aws.String(statistic),
+		},
+		ReturnData: aws.Bool(returnData),
+	}
 }
 
-func (u *UsageStore) getCWMetricsMax(ctx context.Context, metricInput *cloudwatch.GetMetricStatisticsInput) (float64, error) {
-	var maxMetricValue float64
-	output, err := u.cloudwatchClient.GetMetricStatistics(ctx, metricInput)
-	if err != nil {
-		return 0, err
+// aggregate reduces the per-period values of a statistic over the time range: the
+// highest Maximum, lowest Minimum, mean Average, and for a percentile the same
+// percentile of the per-period values. The latter is exact when the period spans
+// the whole time range.
+func aggregate(statistic string, values []float64) float64 {
+	if len(values) == 0 {
+		return 0
 	}
-	for _, dataPoint := range output.Datapoints {
-		maxMetricValue = math.Max(maxMetricValue, *dataPoint.Maximum)
+	switch statistic {
+	case "Maximum":
+		return slices.Max(values)
+	case "Minimum":
+		return slices.Min(values)
+	case "Average":
+		var sum float64
+		for _, value := range values {
+			sum += value
+		}
+		return sum / float64(len(values))
 	}
-	return maxMetricValue, nil
+	percentile, _ := strconv.ParseFloat(strings.TrimPrefix(statistic, "p"), 64)
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	// nearest rank
+	rank := int(math.Ceil(percentile/100*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
 }
 
-func (u *UsageStore) scrapeRDSInfo(ctx context.Context) error {
-	rdsInstances, err := u.rdsClient.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{})
+func (u *UsageStore) scrapeRDSInfo(ctx context.Context, owner *ownership.Matcher, found map[string]InstanceInfo) error {
 	collectedTime := time.Now().UTC()
-	if err != nil {
-		log.ErrorContext(ctx, "failed to scrape rds instances", "error", err)
-		return err
-	}
-	for _, rdsInstance := range rdsInstances.DBInstances {
-		instanceTags := make(map[string]string)
-		for _, tag := range rdsInstance.TagList {
-			instanceTags[*tag.Key] = *tag.Value
+	discovered, matched := 0, 0
+	instances := rds.NewDescribeDBInstancesPaginator(u.rdsClient, &rds.DescribeDBInstancesInput{})
+	for instances.HasMorePages() {
+		page, err := instances.NextPage(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape rds instances", "error", err)
+			return err
 		}
-		if isTagNotExist(u.AWSTags, instanceTags) {
-			continue
+		for _, rdsInstance := range page.DBInstances {
+			discovered++
+			instanceTags := make(map[string]string)
+			for _, tag := range rdsInstance.TagList {
+				instanceTags[*tag.Key] = *tag.Value
+			}
+			if !owner.Owns(aws.ToString(rdsInstance.DBInstanceArn), aws.ToString(rdsInstance.DBInstanceIdentifier), instanceTags) {
+				continue
+			}
+			matched++
+			found[*rdsInstance.DBInstanceIdentifier] = InstanceInfo{
+				Identifier:          aws.ToString(rdsInstance.DBInstanceIdentifier),
+				InstanceClass:       aws.ToString(rdsInstance.DBInstanceClass),
+				Engine:              aws.ToString(rdsInstance.Engine),
+				Level:               "Instance",
+				IdentifierFieldName: "DBInstanceIdentifier",
+				CollectedTime:       collectedTime,
+			}
 		}
-		u.infoStore.Set(*rdsInstance.DBInstanceIdentifier, InstanceInfo{
-			Identifier:          aws.ToString(rdsInstance.DBInstanceIdentifier),
-			InstanceClass:       aws.ToString(rdsInstance.DBInstanceClass),
-			Engine:              aws.ToString(rdsInstance.Engine),
-			Level:               "Instance",
-			IdentifierFieldName: "DBInstanceIdentifier",
-			CollectedTime:       collectedTime,
-		}, u.config.AWSUsageScrapeInterval)
 	}
+	u.setDiscovery("rds", "instance", discovered, matched)
 
-	rdsClusters, err := u.rdsClient.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{})
-	if err != nil {
-		log.ErrorContext(ctx, "failed to scrape rds clusters", "error", err)
-		return err
-	}
-	for _, cluster := range rdsClusters.DBClusters {
-		instanceTags := make(map[string]string)
-		for _, tag := range cluster.TagList {
-			instanceTags[*tag.Key] = *tag.Value
+	discovered, matched = 0, 0
+	clusters := rds.NewDescribeDBClustersPaginator(u.rdsClient, &rds.DescribeDBClustersInput{})
+	for clusters.HasMorePages() {
+		page, err := clusters.NextPage(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape rds clusters", "error", err)
+			return err
 		}
-		if isTagNotExist(u.AWSTags, instanceTags) {
-			continue
+		for _, cluster := range page.DBClusters {
+			discovered++
+			instanceTags := make(map[string]string)
+			for _, tag := range cluster.TagList {
+				instanceTags[*tag.Key] = *tag.Value
+			}
+			if !owner.Owns(aws.ToString(cluster.DBClusterArn), aws.ToString(cluster.DBClusterIdentifier), instanceTags) {
+				continue
+			}
+			matched++
+			instanceClass := aws.ToString(cluster.DBClusterInstanceClass)
+			if instanceClass == "" && cluster.ServerlessV2ScalingConfiguration != nil {
+				instanceClass = serverlessClass
+			}
+			found[*cluster.DBClusterIdentifier] = InstanceInfo{
+				Identifier:          aws.ToString(cluster.DBClusterIdentifier),
+				InstanceClass:       instanceClass,
+				Engine:              aws.ToString(cluster.Engine),
+				Level:               "Cluster",
+				IdentifierFieldName: "DBClusterIdentifier",
+				CollectedTime:       collectedTime,
+			}
 		}
-		u.infoStore.Set(*cluster.DBClusterIdentifier, InstanceInfo{
-			Identifier:          aws.ToString(cluster.DBClusterIdentifier),
-			InstanceClass:       aws.ToString(cluster.DBClusterInstanceClass),
-			Engine:              aws.ToString(cluster.Engine),
-			Level:               "Cluster",
-			IdentifierFieldName: "DBClusterIdentifier",
-			CollectedTime:       collectedTime,
-		}, u.config.AWSUsageScrapeInterval)
 	}
+	u.setDiscovery("rds", "cluster", discovered, matched)
 	return nil
 }
 
-func (u *UsageStore) scrapeDocDBInfo(ctx context.Context) error {
-	docDBInstances, err := u.docdbClient.DescribeDBInstances(ctx, &docdb.DescribeDBInstancesInput{})
+func (u *UsageStore) scrapeDocDBInfo(ctx context.Context, owner *ownership.Matcher, found map[string]InstanceInfo) error {
 	collectedTime := time.Now().UTC()
-	if err != nil {
-		log.ErrorContext(ctx, "failed to scrape docdb", "error", err)
-		return err
-	}
-	for _, instance := range docDBInstances.DBInstances {
-		if !strings.Contains(*instance.DBInstanceIdentifier, u.EnvironmentName) {
-			continue
+	discovered, matched := 0, 0
+	instances := docdb.NewDescribeDBInstancesPaginator(u.docdbClient, &docdb.DescribeDBInstancesInput{})
+	for instances.HasMorePages() {
+		page, err := instances.NextPage(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape docdb", "error", err)
+			return err
+		}
+		for _, instance := range page.DBInstances {
+			discovered++
+			if !owner.Owns(aws.ToString(instance.DBInstanceArn), aws.ToString(instance.DBInstanceIdentifier), nil) {
+				continue
+			}
+			matched++
+			found[*instance.DBInstanceIdentifier] = InstanceInfo{
+				Identifier:          aws.ToString(instance.DBInstanceIdentifier),
+				InstanceClass:       aws.ToString(instance.DBInstanceClass),
+				Engine:              aws.ToString(instance.Engine),
+				Level:               "Instance",
+				IdentifierFieldName: "DBInstanceIdentifier",
+				CollectedTime:       collectedTime,
+			}
 		}
-		u.infoStore.Set(*instance.DBInstanceIdentifier, InstanceInfo{
-			Identifier:          aws.ToString(instance.DBInstanceIdentifier),
-			InstanceClass:       aws.ToString(instance.DBInstanceClass),
-			Engine:              aws.ToString(instance.Engine),
-			Level:               "Instance",
-			IdentifierFieldName: "DBInstanceIdentifier",
-			CollectedTime:       collectedTime,
-		}, u.config.AWSUsageScrapeInterval)
 	}
+	u.setDiscovery("docdb", "instance", discovered, matched)
 
-	docdbClusters, err := u.docdbClient.DescribeDBClusters(ctx, &docdb.DescribeDBClustersInput{})
-	if err != nil {
-		log.ErrorContext(ctx, "failed to scrape docdb clusters", "error", err)
-		return err
-	}
-	for _, cluster := range docdbClusters.DBClusters {
-		if !strings.Contains(*cluster.DBClusterIdentifier, u.EnvironmentName) {
-			continue
+	discovered, matched = 0, 0
+	clusters := docdb.NewDescribeDBClustersPaginator(u.docdbClient, &docdb.DescribeDBClustersInput{})
+	for clusters.HasMorePages() {
+		page, err := clusters.NextPage(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape docdb clusters", "error", err)
+			return err
+		}
+		for _, cluster := range page.DBClusters {
+			discovered++
+			if !owner.Owns(aws.ToString(cluster.DBClusterArn), aws.ToString(cluster.DBClusterIdentifier), nil) {
+				continue
+			}
+			matched++
+			found[*cluster.DBClusterIdentifier] = InstanceInfo{
+				Identifier:          aws.ToString(cluster.DBClusterIdentifier),
+				InstanceClass:       "N/A", // Instance Class is not defined in DBCluster struct
+				Engine:              aws.ToString(cluster.Engine),
+				Level:               "Cluster",
+				IdentifierFieldName: "DBClusterIdentifier",
+				CollectedTime:       collectedTime,
+			}
 		}
-		u.infoStore.Set(*cluster.DBClusterIdentifier, InstanceInfo{
-			Identifier:          aws.ToString(cluster.DBClusterIdentifier),
-			InstanceClass:       "N/A", // Instance Class is not defined in DBCluster struct
-			Engine:              aws.ToString(cluster.Engine),
-			Level:               "Cluster",
-			IdentifierFieldName: "DBClusterIdentifier",
-			CollectedTime:       collectedTime,
-		}, u.config.AWSUsageScrapeInterval)
 	}
+	u.setDiscovery("docdb", "cluster", discovered, matched)
 	return nil
 }
 
-func (u *UsageStore) scrapeElastiCacheInfo(ctx context.Context) error {
-	redisCluster, err := u.elasticacheClient.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{})
+func (u *UsageStore) scrapeElastiCacheInfo(ctx context.Context, owner *ownership.Matcher, found map[string]InstanceInfo) error {
 	collectedTime := time.Now().UTC()
-	if err != nil {
-		log.ErrorContext(ctx, "failed to scrape elasticache clusters", "error", err)
-		return err
+	discovered, matched := 0, 0
+	clusters := elasticache.NewDescribeCacheClustersPaginator(u.elasticacheClient, &elasticache.DescribeCacheClustersInput{})
+	for clusters.HasMorePages() {
+		page, err := clusters.NextPage(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape elasticache clusters", "error", err)
+			return err
+		}
+		for _, cluster := range page.CacheClusters {
+			discovered++
+			if !owner.Owns(aws.ToString(cluster.ARN), aws.ToString(cluster.CacheClusterId), nil) {
+				continue
+			}
+			matched++
+			found[*cluster.CacheClusterId] = InstanceInfo{
+				Identifier:          aws.ToString(cluster.CacheClusterId),
+				InstanceClass:       aws.ToString(cluster.CacheNodeType),
+				Engine:              aws.ToString(cluster.Engine),
+				Level:               "Cluster",
+				IdentifierFieldName: "CacheClusterId",
+				CollectedTime:       collectedTime,
+			}
+		}
 	}
-	for _, cluster := range redisCluster.CacheClusters {
-		if !strings.Contains(*cluster.CacheClusterId, u.EnvironmentName) {
-			continue
+	u.setDiscovery("elasticache", "cluster", discovered, matched)
+
+	discovered, matched = 0, 0
+	serverlessCaches := elasticache.NewDescribeServerlessCachesPaginator(u.elasticacheClient, &elasticache.DescribeServerlessCachesInput{})
+	for serverlessCaches.HasMorePages() {
+		page, err := serverlessCaches.NextPage(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape elasticache serverless caches", "error", err)
+			return err
+		}
+		for _, cache := range page.ServerlessCaches {
+			discovered++
+			if !owner.Owns(aws.ToString(cache.ARN), aws.ToString(cache.ServerlessCacheName), nil) {
+				continue
+			}
+			matched++
+			found[*cache.ServerlessCacheName] = InstanceInfo{
+				Identifier:          aws.ToString(cache.ServerlessCacheName),
+				InstanceClass:       "serverless",
+				Engine:              aws.ToString(cache.Engine),
+				Level:               "Serverless",
+				IdentifierFieldName: "clusterId",
+				CollectedTime:       collectedTime,
+			}
 		}
-		u.infoStore.Set(*cluster.CacheClusterId, InstanceInfo{
-			Identifier:          aws.ToString(cluster.CacheClusterId),
-			InstanceClass:       aws.ToString(cluster.CacheNodeType),
-			Engine:              aws.ToString(cluster.Engine),
-			Level:               "Cluster",
-			IdentifierFieldName: "CacheClusterId",
-			CollectedTime:       collectedTime,
-		}, u.config.AWSUsageScrapeInterval)
 	}
+	u.setDiscovery("elasticache", "serverless", discovered, matched)
 	return nil
 }
 
-func (u *UsageStore) scrapeKafkaInfo(ctx context.Context) error {
-	kafkaClusters, err := u.kafkaClient.ListClustersV2(ctx, &kafka.ListClustersV2Input{})
+func (u *UsageStore) scrapeKafkaInfo(ctx context.Context, owner *ownership.Matcher, found map[string]InstanceInfo) error {
 	collectedTime := time.Now().UTC()
-	if err != nil {
-		log.ErrorContext(ctx, "failed to scrape kafka clusters", "error", err)
-		return err
-	}
-
-	for _, kafkaCluster := range kafkaClusters.ClusterInfoList {
-		if !strings.Contains(*kafkaCluster.ClusterName, u.EnvironmentName) {
-			continue
+	discovered, matched := 0, 0
+	clusters := kafka.NewListClustersV2Paginator(u.kafkaClient, &kafka.ListClustersV2Input{})
+	for clusters.HasMorePages() {
+		page, err := clusters.NextPage(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape kafka clusters", "error", err)
+			return err
 		}
-		u.infoStore.Set(*kafkaCluster.ClusterName, InstanceInfo{
-			Identifier:          *kafkaCluster.ClusterName,
-			InstanceClass:       *kafkaCluster.Provisioned.BrokerNodeGroupInfo.InstanceType,
-			Engine:              "kafka",
-			Level:               "Cluster",
-			IdentifierFieldName: "Cluster Name",
-			BrokerCount:         int(*kafkaCluster.Provisioned.NumberOfBrokerNodes),
-			CollectedTime:       collectedTime,
-		}, u.config.AWSUsageScrapeInterval)
-		for i := 1; i <= int(*kafkaCluster.Provisioned.NumberOfBrokerNodes); i++ {
-			u.infoStore.Set(fmt.Sprintf("%s-%d", *kafkaCluster.ClusterName, i), InstanceInfo{
+		for _, kafkaCluster := range page.ClusterInfoList {
+			discovered++
+			// serverless clusters have no brokers to size
+			if !owner.Owns(aws.ToString(kafkaCluster.ClusterArn), aws.ToString(kafkaCluster.ClusterName), kafkaCluster.Tags) || kafkaCluster.Provisioned == nil {
+				continue
+			}
+			matched++
+			brokerCount := int(aws.ToInt32(kafkaCluster.Provisioned.NumberOfBrokerNodes))
+			instanceType := aws.ToString(kafkaCluster.Provisioned.BrokerNodeGroupInfo.InstanceType)
+			found[*kafkaCluster.ClusterName] = InstanceInfo{
 				Identifier:          *kafkaCluster.ClusterName,
-				InstanceClass:       *kafkaCluster.Provisioned.BrokerNodeGroupInfo.InstanceType,
+				InstanceClass:       instanceType,
 				Engine:              "kafka",
-				Level:               "Broker",
+				Level:               "Cluster",
 				IdentifierFieldName: "Cluster Name",
-				BrokerID:            fmt.Sprint(i),
-				BrokerCount:         int(*kafkaCluster.Provisioned.NumberOfBrokerNodes),
+				BrokerCount:         brokerCount,
 				CollectedTime:       collectedTime,
-			}, u.config.AWSUsageScrapeInterval)
+			}
+			for i := 1; i <= brokerCount; i++ {
+				found[fmt.Sprintf("%s-%d", *kafkaCluster.ClusterName, i)] = InstanceInfo{
+					Identifier:          *kafkaCluster.ClusterName,
+					InstanceClass:       instanceType,
+					Engine:              "kafka",
+					Level:               "Broker",
+					IdentifierFieldName: "Cluster Name",
+					BrokerID:            fmt.Sprint(i),
+					BrokerCount:         brokerCount,
+					CollectedTime:       collectedTime,
+				}
+			}
 		}
 	}
+	u.setDiscovery("kafka", "cluster", discovered, matched)
 	return nil
 }
 
-func isTagNotExist(expectedTags, targetTags map[string]string) bool {
-	tagsMatchMap := make(map[string]bool)
-	for k, v := range expectedTags {
-		for tk, tv := range targetTags {
-			tagsMatched := k == tk && v == tv
-			tagsMatchMap[k] = tagsMatched
-			if tagsMatched {
-				log.Debug("debug me this", k, v)
-				break
+// describeDomainsLimit is the most domains a DescribeDomains call accepts.
+const describeDomainsLimit = 5
+
+func (u *UsageStore) scrapeOpenSearchInfo(ctx context.Context, owner *ownership.Matcher, found map[string]InstanceInfo) error {
+	collectedTime := time.Now().UTC()
+	// ListDomainNames is not paginated, the domains are then described in batches
+	list, err := u.opensearchClient.ListDomainNames(ctx, &opensearch.ListDomainNamesInput{})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to scrape opensearch domains", "error", err)
+		return err
+	}
+	names := make([]string, 0, len(list.DomainNames))
+	for _, domain := range list.DomainNames {
+		names = append(names, aws.ToString(domain.DomainName))
+	}
+
+	discovered, matched := 0, 0
+	for batch := range slices.Chunk(names, describeDomainsLimit) {
+		output, err := u.opensearchClient.DescribeDomains(ctx, &opensearch.DescribeDomainsInput{DomainNames: batch})
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scrape opensearch domains", "error", err)
+			return err
+		}
+		for _, domain := range output.DomainStatusList {
+			discovered++
+			if !owner.Owns(aws.ToString(domain.ARN), aws.ToString(domain.DomainName), nil) {
+				continue
+			}
+			domainARN, err := arn.Parse(aws.ToString(domain.ARN))
+			if err != nil {
+				log.ErrorContext(ctx, "failed to parse opensearch domain arn", "error", err, "domain", aws.ToString(domain.DomainName))
+				continue
+			}
+			matched++
+			info := InstanceInfo{
+				Identifier:          aws.ToString(domain.DomainName),
+				Engine:              "opensearch",
+				Level:               "Domain",
+				IdentifierFieldName: "DomainName",
+				AccountID:           domainARN.AccountID,
+				CollectedTime:       collectedTime,
 			}
+			if domain.ClusterConfig != nil {
+				info.InstanceClass = string(domain.ClusterConfig.InstanceType)
+			}
+			if domain.EBSOptions != nil && aws.ToBool(domain.EBSOptions.EBSEnabled) {
+				info.StorageSize = int(aws.ToInt32(domain.EBSOptions.VolumeSize))
+			}
+			found[info.Identifier] = info
 		}
 	}
-	isTagMismatch := false
-	for _, v := range tagsMatchMap {
-		isTagMismatch = isTagMismatch || !v
-	}
-	return isTagMismatch && len(tagsMatchMap) != 3
+	u.setDiscovery("opensearch", "domain", discovered, matched)
+	return nil
+}
+
+// Discovery counts the resources listed for a service and those that belong to
+// the environment.
+type Discovery struct {
+	Service    string
+	Resource   string
+	Discovered int
+	Matched    int
+}
+
+func (u *UsageStore) setDiscovery(service, resource string, discovered, matched int) {
+	u.discoveryMu.Lock()
+	defer u.discoveryMu.Unlock()
+	u.discovery[service+"/"+resource] = Discovery{Service: service, Resource: resource, Discovered: discovered, Matched: matched}
+}
+
+// Discovery returns the counts of the last discovery of every service.
+func (u *UsageStore) Discovery() []Discovery {
+	u.discoveryMu.Lock()
+	defer u.discoveryMu.Unlock()
+	discovery := slices.Collect(maps.Values(u.discovery))
+	slices.SortFunc(discovery, func(a, b Discovery) int {
+		return strings.Compare(a.Service+"/"+a.Resource, b.Service+"/"+b.Resource)
+	})
+	return discovery
 }
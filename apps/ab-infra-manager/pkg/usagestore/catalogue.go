@@ -0,0 +1,288 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package usagestore
+
+import (
+	"accelbyte/ab-infra-manager/pkg/instanceclass"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// serverlessClass is the instance class of Aurora Serverless v2 instances.
+const serverlessClass = "db.serverless"
+
+// Usage series with a field of their own in Usage, every other series is in
+// Usage.Series.
+const (
+	seriesCPU    = "cpu"
+	seriesMemory = "memory"
+)
+
+// bytesPerGiB converts byte metrics to GiB.
+const bytesPerGiB = 1 << 30
+
+// metricDefinition is a usage series read from one CloudWatch metric, or the sum
+// of several.
+type metricDefinition struct {
+	series      string
+	metricNames []string
+	// statistic replaces the configured statistics, e.g. Minimum for free memory
+	// where the lowest value is the peak usage
+	statistic string
+	// scale converts the unit of the values, e.g. bytes to GiB
+	scale float64
+	// capacity of a free space metric, the series is then the percentage used.
+	// The series is skipped when the capacity is unknown.
+	capacity func(instance InstanceInfo) float64
+	// levels and classes restrict the metric to some instances, all when empty
+	levels  []string
+	classes []string
+}
+
+// engineMetrics are the metrics of an engine in a CloudWatch namespace.
+type engineMetrics struct {
+	namespace string
+	// dimensions added to the identifier dimension, read from the instance with
+	// dimensionValues
+	dimensions []string
+	metrics    []metricDefinition
+}
+
+// dimensionValues read the value of extra dimensions from an instance.
+var dimensionValues = map[string]func(instance InstanceInfo) string{
+	"ClientId":  func(instance InstanceInfo) string { return instance.AccountID },
+	"Broker ID": func(instance InstanceInfo) string { return instance.BrokerID },
+}
+
+// classMemory is the memory of the instance class in bytes, the capacity of
+// FreeableMemory. RDS and DocumentDB publish no memory usage percentage.
+func classMemory(instance InstanceInfo) float64 {
+	class, ok := instanceclass.Parse(instance.InstanceClass)
+	if !ok {
+		return 0
+	}
+	return class.MemoryGiB() * bytesPerGiB
+}
+
+var rdsMetrics = []metricDefinition{
+	{series: seriesCPU, metricNames: []string{"CPUUtilization"}},
+	{series: seriesMemory, metricNames: []string{"FreeableMemory"}, capacity: classMemory},
+	{series: "freeable_memory_gib", metricNames: []string{"FreeableMemory"}, statistic: "Minimum", scale: 1.0 / bytesPerGiB},
+	{series: "connections", metricNames: []string{"DatabaseConnections"}},
+	{series: "read_iops", metricNames: []string{"ReadIOPS"}},
+	{series: "write_iops", metricNames: []string{"WriteIOPS"}},
+}
+
+// Aurora storage grows with the data, so there is no free storage to report.
+var auroraMetrics = append(slices.Clone(rdsMetrics),
+	// Aurora Serverless v2 is sized in ACUs instead of instance classes
+	metricDefinition{series: "acu_utilization", metricNames: []string{"ACUUtilization"}, classes: []string{serverlessClass}},
+	metricDefinition{series: "capacity_acu", metricNames: []string{"ServerlessDatabaseCapacity"}, classes: []string{serverlessClass}},
+)
+
+var rdsStorageMetrics = append(slices.Clone(rdsMetrics),
+	metricDefinition{series: "free_storage_gib", metricNames: []string{"FreeStorageSpace"}, statistic: "Minimum", scale: 1.0 / bytesPerGiB},
+)
+
+var elastiCacheMetrics = []metricDefinition{
+	{series: seriesCPU, metricNames: []string{"CPUUtilization"}, levels: []string{"Cluster"}},
+	{series: seriesMemory, metricNames: []string{"DatabaseMemoryUsagePercentage"}, levels: []string{"Cluster"}},
+	{series: "connections", metricNames: []string{"CurrConnections"}, levels: []string{"Cluster"}},
+	// serverless caches publish no CPUUtilization
+	{series: "ecpu", metricNames: []string{"ElastiCacheProcessingUnits"}, levels: []string{"Serverless"}},
+	{series: "bytes_used", metricNames: []string{"BytesUsedForCache"}, levels: []string{"Serverless"}},
+}
+
+// catalogue lists the usage series of each engine. A new series is added here,
+// GetUsage returns it in Usage.Series and it is exported as aws_resource_usage_<series>.
+var catalogue = map[string]engineMetrics{
+	"postgres":          {namespace: "AWS/RDS", metrics: rdsStorageMetrics},
+	"mysql":             {namespace: "AWS/RDS", metrics: rdsStorageMetrics},
+	"aurora-postgresql": {namespace: "AWS/RDS", metrics: auroraMetrics},
+	"aurora-mysql":      {namespace: "AWS/RDS", metrics: auroraMetrics},
+	"docdb": {namespace: "AWS/DocDB", metrics: []metricDefinition{
+		{series: seriesCPU, metricNames: []string{"CPUUtilization"}},
+		{series: seriesMemory, metricNames: []string{"FreeableMemory"}, capacity: classMemory},
+		{series: "freeable_memory_gib", metricNames: []string{"FreeableMemory"}, statistic: "Minimum", scale: 1.0 / bytesPerGiB},
+		{series: "connections", metricNames: []string{"DatabaseConnections"}},
+		{series: "read_iops", metricNames: []string{"ReadIOPS"}},
+		{series: "write_iops", metricNames: []string{"WriteIOPS"}},
+	}},
+	"redis":  {namespace: "AWS/ElastiCache", metrics: elastiCacheMetrics},
+	"valkey": {namespace: "AWS/ElastiCache", metrics: elastiCacheMetrics},
+	"memcached": {namespace: "AWS/ElastiCache", metrics: []metricDefinition{
+		{series: seriesCPU, metricNames: []string{"CPUUtilization"}, levels: []string{"Cluster"}},
+		{series: "connections", metricNames: []string{"CurrConnections"}, levels: []string{"Cluster"}},
+		{series: "ecpu", metricNames: []string{"ElastiCacheProcessingUnits"}, levels: []string{"Serverless"}},
+		{series: "bytes_used", metricNames: []string{"BytesUsedForCache"}, levels: []string{"Serverless"}},
+	}},
+	"opensearch": {namespace: "AWS/ES", dimensions: []string{"ClientId"}, metrics: []metricDefinition{
+		{series: seriesCPU, metricNames: []string{"CPUUtilization"}},
+		{series: seriesMemory, metricNames: []string{"JVMMemoryPressure"}},
+		// FreeStorageSpace is in MiB, per data node
+		{series: "storage", metricNames: []string{"FreeStorageSpace"}, capacity: func(instance InstanceInfo) float64 {
+			return float64(instance.StorageSize) * 1024
+		}},
+	}},
+	// metrics of brokers, a cluster reports the highest value of its brokers
+	"kafka": {namespace: "AWS/Kafka", dimensions: []string{"Broker ID"}, metrics: []metricDefinition{
+		// MSK does not publish a total CPU
+		{series: seriesCPU, metricNames: []string{"CpuSystem", "CpuUser"}},
+		{series: "disk_used", metricNames: []string{"KafkaDataLogsDiskUsed"}},
+		{series: "bytes_in", metricNames: []string{"BytesInPerSec"}},
+		{series: "bytes_out", metricNames: []string{"BytesOutPerSec"}},
+	}},
+}
+
+func (m metricDefinition) appliesTo(instance InstanceInfo) bool {
+	return (len(m.levels) == 0 || slices.Contains(m.levels, instance.Level)) &&
+		(len(m.classes) == 0 || slices.Contains(m.classes, instance.InstanceClass))
+}
+
+// metricRequest is a CloudWatch metric of an instance, cached under key with one
+// value per statistic.
+type metricRequest struct {
+	key        string
+	series     string
+	start      time.Time
+	end        time.Time
+	namespace  string
+	dimensions []types.Dimension
+	// metricNames are added up with metric math, e.g. CpuSystem+CpuUser for Kafka
+	metricNames []string
+	statistic   string
+	scale       float64
+	capacity    float64
+}
+
+// metricRequests lists the metrics of the catalogue needed for the usage of an
+// instance. A Kafka cluster needs the metrics of each of its brokers. Engines
+// missing from the catalogue get none.
+func (u *UsageStore) metricRequests(instance InstanceInfo) []metricRequest {
+	engine, ok := catalogue[instance.Engine]
+	if !ok {
+		return nil
+	}
+	if instance.Engine == "kafka" && instance.Level == "Cluster" {
+		var requests []metricRequest
+		for _, broker := range kafkaBrokers(instance) {
+			requests = append(requests, u.metricRequests(broker)...)
+		}
+		return requests
+	}
+
+	dimensions := []types.Dimension{{Name: aws.String(instance.IdentifierFieldName), Value: aws.String(instance.Identifier)}}
+	for _, name := range engine.dimensions {
+		dimensions = append(dimensions, types.Dimension{Name: aws.String(name), Value: aws.String(dimensionValues[name](instance))})
+	}
+
+	var requests []metricRequest
+	for _, metric := range engine.metrics {
+		if !metric.appliesTo(instance) {
+			continue
+		}
+		request := metricRequest{
+			key:         seriesKey(instance, metric.series),
+			series:      metric.series,
+			start:       instance.CollectedTime.Add(-u.config().AWSUsageTimeRange),
+			end:         instance.CollectedTime,
+			namespace:   engine.namespace,
+			dimensions:  dimensions,
+			metricNames: metric.metricNames,
+			statistic:   metric.statistic,
+			scale:       metric.scale,
+		}
+		if metric.capacity != nil {
+			request.capacity = metric.capacity(instance)
+			if request.capacity == 0 {
+				continue
+			}
+		}
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+// kafkaBrokers returns the brokers of a Kafka cluster.
+func kafkaBrokers(cluster InstanceInfo) []InstanceInfo {
+	brokers := make([]InstanceInfo, 0, cluster.BrokerCount)
+	for i := 1; i <= cluster.BrokerCount; i++ {
+		broker := cluster
+		broker.Level = "Broker"
+		broker.BrokerID = fmt.Sprint(i)
+		brokers = append(brokers, broker)
+	}
+	return brokers
+}
+
+// seriesKey is the metric store key of a series, the usage key suffixed with
+// /<series> except for CPU.
+func seriesKey(instance InstanceInfo, series string) string {
+	if series == seriesCPU {
+		return usageKey(instance)
+	}
+	return usageKey(instance) + "/" + series
+}
+
+// instanceSeries lists the series of the requests of an instance, once each.
+func instanceSeries(requests []metricRequest) []string {
+	var series []string
+	for _, request := range requests {
+		if !slices.Contains(series, request.series) {
+			series = append(series, request.series)
+		}
+	}
+	return series
+}
+
+// statistics are the statistics of the request, its own or the configured ones.
+func (r metricRequest) statistics(configured []string) []string {
+	if r.statistic != "" {
+		return []string{r.statistic}
+	}
+	return configured
+}
+
+// stat is the CloudWatch statistic queried for statistic. Free space is queried
+// with the mirrored statistic, as the Minimum free space is the Maximum used.
+func (r metricRequest) stat(statistic string) string {
+	if r.capacity == 0 {
+		return statistic
+	}
+	switch statistic {
+	case "Maximum":
+		return "Minimum"
+	case "Minimum":
+		return "Maximum"
+	case "Average":
+		return statistic
+	}
+	percentile, _ := strconv.ParseFloat(strings.TrimPrefix(statistic, "p"), 64)
+	return "p" + strconv.FormatFloat(100-percentile, 'f', -1, 64)
+}
+
+// convert applies the unit conversion of the request to its per-period values:
+// the percentage used of the capacity for free space, or the scale.
+func (r metricRequest) convert(values []float64) []float64 {
+	if r.capacity == 0 && r.scale == 0 {
+		return values
+	}
+	converted := make([]float64, 0, len(values))
+	for _, value := range values {
+		if r.capacity != 0 {
+			value = 100 * (1 - value/r.capacity)
+		} else {
+			value *= r.scale
+		}
+		converted = append(converted, value)
+	}
+	return converted
+}
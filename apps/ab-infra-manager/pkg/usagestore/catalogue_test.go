@@ -0,0 +1,53 @@
+package usagestore
+
+import (
+	"accelbyte/ab-infra-manager/pkg/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDatabaseMemoryRequest(t *testing.T) {
+	u := &UsageStore{config: func() *models.Cfg { return &models.Cfg{AWSUsageTimeRange: 24 * time.Hour} }}
+	memoryRequest := func(engine, class string) (metricRequest, bool) {
+		instance := InstanceInfo{Identifier: "db", IdentifierFieldName: "DBInstanceIdentifier", Engine: engine, InstanceClass: class, Level: "Instance"}
+		for _, request := range u.metricRequests(instance) {
+			if request.series == seriesMemory {
+				return request, true
+			}
+		}
+		return metricRequest{}, false
+	}
+
+	tests := []struct {
+		engine, class string
+		capacityGiB   float64
+	}{
+		{"postgres", "db.r6g.xlarge", 32},
+		{"aurora-mysql", "db.t4g.medium", 4},
+		{"docdb", "db.r6g.large", 16},
+		{"aurora-postgresql", "db.serverless", 0},
+		{"mysql", "db.r6g.metal", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.engine+" "+tt.class, func(t *testing.T) {
+			request, ok := memoryRequest(tt.engine, tt.class)
+			if ok != (tt.capacityGiB != 0) {
+				t.Fatalf("memory requested = %v, want %v", ok, tt.capacityGiB != 0)
+			}
+			if ok && request.capacity != tt.capacityGiB*bytesPerGiB {
+				t.Errorf("capacity = %v, want %v GiB", request.capacity, tt.capacityGiB)
+			}
+		})
+	}
+
+	// 8 GiB freeable of 32 GiB is 75% used, the 5th percentile of the free memory
+	// is the 95th of the usage
+	request, _ := memoryRequest("postgres", "db.r6g.xlarge")
+	if got := request.convert([]float64{8 * bytesPerGiB})[0]; math.Abs(got-75) > 1e-9 {
+		t.Errorf("convert(8 GiB) = %v, want 75", got)
+	}
+	if got := request.stat("p95"); got != "p5" {
+		t.Errorf("stat(p95) = %q, want p5", got)
+	}
+}
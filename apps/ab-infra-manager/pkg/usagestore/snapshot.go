@@ -0,0 +1,142 @@
+// Copyright (c) 2025 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package usagestore
+
+import (
+	"accelbyte/ab-infra-manager/pkg/persistence"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Snapshot is the usage of one scrape window. The last one is restored at startup,
+// the retained ones give the trend over previous windows.
+type Snapshot struct {
+	Time time.Time `json:"time"`
+	// Window is the end of the usage window, when the instances were discovered.
+	Window    time.Time      `json:"window"`
+	Instances []InstanceInfo `json:"instances"`
+	// Usage also refills the metric cache on restore, see usageMetrics.
+	Usage []Usage `json:"usage"`
+}
+
+// Restore loads the snapshots of backend, and refills the caches from the last one
+// while it is younger than the scrape interval. Later usage fetched from AWS is
+// stored in backend.
+func (u *UsageStore) Restore(ctx context.Context, backend persistence.Backend) error {
+	u.historyMu.Lock()
+	defer u.historyMu.Unlock()
+	u.persistence = backend
+
+	encoded, err := backend.Load(ctx)
+	if err != nil {
+		return err
+	}
+	u.history = nil
+	for _, data := range encoded {
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			log.WarnContext(ctx, "skipping unreadable usage snapshot", "error", err)
+			continue
+		}
+		u.history = append(u.history, snapshot)
+	}
+	if len(u.history) == 0 {
+		return nil
+	}
+
+	last := u.history[len(u.history)-1]
+	ttl := time.Until(last.Window.Add(u.config().AWSUsageScrapeInterval))
+	if ttl <= 0 {
+		log.InfoContext(ctx, "last usage snapshot expired", "window", last.Window, "snapshots", len(u.history))
+		return nil
+	}
+	u.infoStore.Set(instancesKey, last.Instances, ttl)
+	for key, values := range usageMetrics(last.Usage) {
+		u.metricStore.Set(key, values, ttl)
+	}
+	log.InfoContext(ctx, "restored usage snapshot", "window", last.Window, "instances", len(last.Instances), "snapshots", len(u.history))
+	return nil
+}
+
+// History returns the retained snapshots, oldest first.
+func (u *UsageStore) History() []Snapshot {
+	u.historyMu.Lock()
+	defer u.historyMu.Unlock()
+	return slices.Clone(u.history)
+}
+
+// saveSnapshot records the usage just fetched in the history, and in the
+// persistence backend if any. A window retried after errors replaces its snapshot.
+// Failures are logged, the usage is still served.
+func (u *UsageStore) saveSnapshot(ctx context.Context, instances []InstanceInfo, usages []Usage) {
+	snapshot := Snapshot{
+		Time:      time.Now().UTC(),
+		Window:    window(instances),
+		Instances: instances,
+		Usage:     usages,
+	}
+	keep := u.config().UsageHistory
+
+	u.historyMu.Lock()
+	if n := len(u.history); n > 0 && u.history[n-1].Window.Equal(snapshot.Window) {
+		u.history[n-1] = snapshot
+	} else {
+		u.history = append(u.history, snapshot)
+	}
+	u.history = u.history[max(0, len(u.history)-keep):]
+	backend := u.persistence
+	u.historyMu.Unlock()
+
+	// the backend may be the Kubernetes API, it is written without historyMu
+	if backend == nil {
+		return
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to encode usage snapshot", "error", err)
+		return
+	}
+	// zero padded, so IDs sort by window
+	id := fmt.Sprintf("%020d", snapshot.Window.UnixNano())
+	if err := backend.Put(ctx, id, data, keep); err != nil {
+		log.ErrorContext(ctx, "failed to persist usage snapshot", "error", err, "bytes", len(data))
+	}
+}
+
+// usageMetrics returns the metric cache entries the usages were read from. Kafka
+// clusters are skipped, their usage is the peak of their brokers.
+func usageMetrics(usages []Usage) map[string]map[string]float64 {
+	items := make(map[string]map[string]float64)
+	for _, usage := range usages {
+		if usage.Engine == "kafka" && usage.Level == "Cluster" {
+			continue
+		}
+		if usage.CPU != nil {
+			items[seriesKey(usage.InstanceInfo, seriesCPU)] = usage.CPU
+		}
+		if usage.Memory != nil {
+			items[seriesKey(usage.InstanceInfo, seriesMemory)] = usage.Memory
+		}
+		for name, values := range usage.Series {
+			items[seriesKey(usage.InstanceInfo, name)] = values
+		}
+	}
+	return items
+}
+
+// window is the end of the usage window of instances, the time they were last
+// discovered.
+func window(instances []InstanceInfo) time.Time {
+	var end time.Time
+	for _, instance := range instances {
+		if instance.CollectedTime.After(end) {
+			end = instance.CollectedTime
+		}
+	}
+	return end
+}
@@ -14,11 +14,18 @@ type Blueprint struct {
 }
 
 // Resource defines a single resource to be validated.
+//
+// Severity applies to every difference found on the resource (default: error),
+// Severities overrides it per attribute and Ignore skips attributes entirely.
+// Attributes are matched by the name shown in the report, including nested ones.
 type Resource struct {
-	Name      string                 `yaml:"name"`
-	Type      string                 `yaml:"type"`
-	Namespace string                 `yaml:"namespace,omitempty"`
-	Spec      map[string]interface{} `yaml:"spec"`
+	Name       string                 `yaml:"name"`
+	Type       string                 `yaml:"type"`
+	Namespace  string                 `yaml:"namespace,omitempty"`
+	Severity   string                 `yaml:"severity,omitempty"`
+	Severities map[string]string      `yaml:"severities,omitempty"`
+	Ignore     []string               `yaml:"ignore,omitempty"`
+	Spec       map[string]interface{} `yaml:"spec"`
 }
 
 // LoadBlueprint reads and parses a blueprint file from the ./blueprints directory
@@ -34,5 +41,11 @@ func LoadBlueprint(name string) (*Blueprint, error) {
 		return nil, fmt.Errorf("could not parse blueprint YAML %s: %w", path, err)
 	}
 
+	for _, res := range bp.Resources {
+		if err := validateSeverities(res); err != nil {
+			return nil, fmt.Errorf("invalid blueprint %s: %w", path, err)
+		}
+	}
+
 	return &bp, nil
 }
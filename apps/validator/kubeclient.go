@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// offlineObjects caches the objects read from offlineObjectsPath, so the files are
+// only parsed once per run.
+var (
+	offlineObjects     []*unstructured.Unstructured
+	offlineObjectsPath string
+)
+
+// newKubernetesClientset returns a typed clientset for the cluster in kubeconfigPath.
+// In offline and replay mode the clientset is backed by manifests instead.
+func newKubernetesClientset() (kubernetes.Interface, error) {
+	if !offlineMode && replayPath == "" {
+		config, err := kubeRestConfig()
+		if err != nil {
+			return nil, err
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+		}
+		return clientset, nil
+	}
+
+	objs, err := loadOfflineObjects()
+	if err != nil {
+		return nil, err
+	}
+	// Only kinds known to the built-in scheme can be served by the typed fake;
+	// CRDs are served by the dynamic client.
+	var typed []runtime.Object
+	for _, obj := range objs {
+		typedObj, err := scheme.Scheme.New(obj.GroupVersionKind())
+		if err != nil {
+			continue
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), typedObj); err != nil {
+			return nil, fmt.Errorf("failed to convert %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		typed = append(typed, typedObj)
+	}
+	return fake.NewSimpleClientset(typed...), nil
+}
+
+// newDynamicClient returns a dynamic client for the cluster in kubeconfigPath.
+// In offline and replay mode the client is backed by manifests instead.
+func newDynamicClient() (dynamic.Interface, error) {
+	if !offlineMode && replayPath == "" {
+		config, err := kubeRestConfig()
+		if err != nil {
+			return nil, err
+		}
+		client, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes dynamic client: %w", err)
+		}
+		return client, nil
+	}
+
+	objs, err := loadOfflineObjects()
+	if err != nil {
+		return nil, err
+	}
+	untyped := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		untyped = append(untyped, obj.DeepCopy())
+	}
+	return dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), untyped...), nil
+}
+
+// loadOfflineObjects reads every object from manifestsPath, which is either a single
+// multi-document YAML file (e.g. `kustomize build` output) or a directory of them.
+// When replaying, the objects come from the recorded fixtures instead.
+func loadOfflineObjects() ([]*unstructured.Unstructured, error) {
+	source := manifestsPath
+	if replayPath != "" {
+		source = filepath.Join(replayPath, kubernetesFixtureFile)
+	}
+	if offlineObjects != nil && offlineObjectsPath == source {
+		return offlineObjects, nil
+	}
+	if source == "" {
+		return nil, fmt.Errorf("--manifests is required to validate Kubernetes resources in offline mode")
+	}
+
+	var files []string
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not read manifests %s: %w", source, err)
+	}
+	if info.IsDir() {
+		err = filepath.WalkDir(source, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not walk manifests directory %s: %w", source, err)
+		}
+	} else {
+		files = []string{source}
+	}
+
+	// The fake clients refuse duplicates, so the last definition of an object wins.
+	objs := []*unstructured.Unstructured{}
+	seen := make(map[string]int)
+	for _, file := range files {
+		fileObjs, err := decodeManifests(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range fileObjs {
+			key := fmt.Sprintf("%s/%s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+			if i, exists := seen[key]; exists {
+				objs[i] = obj
+				continue
+			}
+			seen[key] = len(objs)
+			objs = append(objs, obj)
+		}
+	}
+
+	offlineObjects = objs
+	offlineObjectsPath = source
+	return offlineObjects, nil
+}
+
+// decodeManifests decodes all documents of a YAML file, flattening any v1 List.
+func decodeManifests(file string) ([]*unstructured.Unstructured, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read manifest file %s: %w", file, err)
+	}
+
+	var objs []*unstructured.Unstructured
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read manifest file %s: %w", file, err)
+		}
+		jsonDoc, err := utilyaml.ToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse manifest file %s: %w", file, err)
+		}
+		if trimmed := bytes.TrimSpace(jsonDoc); len(trimmed) == 0 || string(trimmed) == "null" {
+			continue // Empty document, e.g. a trailing "---".
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(jsonDoc); err != nil {
+			return nil, fmt.Errorf("could not decode object in manifest file %s: %w", file, err)
+		}
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				objs = append(objs, item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("could not read list in manifest file %s: %w", file, err)
+			}
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
@@ -4,17 +4,38 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 )
 
 // Declare a global variable to hold the kubeconfig path.
 var kubeconfigPath string
 
+// Offline mode validates the files of an IaC change instead of the live environment.
+var (
+	offlineMode       bool
+	manifestsPath     string
+	terragruntMapPath string
+)
+
+// Record mode captures the AWS and Kubernetes API responses of a live run as fixtures,
+// replay mode serves them back without any cloud or cluster access.
+var (
+	recordPath string
+	replayPath string
+)
+
 func main() {
 	// 1. Define CLI Flags
 	environment := flag.String("environment", "", "The environment ID being validated (for logging)")
 	blueprintName := flag.String("blueprint", "", "The name of the blueprint to validate against")
 	// Add the new --kubeconfig flag.
 	flag.StringVar(&kubeconfigPath, "kubeconfig", "", "Absolute path to the kubeconfig file (optional)")
+	flag.BoolVar(&offlineMode, "offline", false, "Validate rendered manifests and terragrunt files instead of the live environment")
+	flag.StringVar(&manifestsPath, "manifests", "", "Rendered Kubernetes manifests (file or directory) used in offline mode")
+	flag.StringVar(&terragruntMapPath, "terragrunt-map", "", "YAML file mapping resource names to terragrunt files, used in offline mode")
+	flag.StringVar(&recordPath, "record", "", "Directory to record AWS and Kubernetes API responses into (optional)")
+	flag.StringVar(&replayPath, "replay", "", "Directory of recorded API responses to validate against instead of the live environment (optional)")
+	waiversPath := flag.String("waivers", "", "YAML file listing accepted differences with owner and expiry date (optional)")
 	flag.Parse()
 
 	if *environment == "" || *blueprintName == "" {
@@ -25,6 +46,27 @@ func main() {
 
 	fmt.Printf("🚀 Starting validation for environment '%s' against blueprint '%s'...\n", *environment, *blueprintName)
 
+	if (offlineMode && (recordPath != "" || replayPath != "")) || (recordPath != "" && replayPath != "") {
+		fmt.Println("Error: --offline, --record and --replay cannot be combined.")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if replayPath != "" {
+		fmt.Printf("📼 Replaying recorded API responses from %s.\n", replayPath)
+	}
+
+	if offlineMode {
+		fmt.Println("📦 Running in offline mode, no cloud or cluster access will be used.")
+		if terragruntMapPath != "" {
+			tm, err := LoadTerragruntMap(terragruntMapPath)
+			if err != nil {
+				fmt.Printf("Error loading terragrunt map: %v\n", err)
+				os.Exit(1)
+			}
+			terragruntMap = tm
+		}
+	}
+
 	// ... rest of the function remains the same ...
 	// 2. Load Desired State
 	blueprint, err := LoadBlueprint(*blueprintName)
@@ -35,21 +77,56 @@ func main() {
 
 	// 3. Run Validation
 	differences, err := RunValidation(blueprint)
+	if recordPath != "" {
+		// Save even when validation failed, so the failing responses can be replayed.
+		if saveErr := SaveRecording(); saveErr != nil {
+			fmt.Printf("Error saving recording: %v\n", saveErr)
+			os.Exit(1)
+		}
+		fmt.Printf("📼 Recorded API responses to %s.\n", recordPath)
+	}
 	if err != nil {
 		fmt.Printf("A fatal error occurred during validation: %v\n", err)
 		os.Exit(1)
 	}
 
-	// 4. Report Results
+	// 4. Apply Waivers
+	var expiredWaivers []Waiver
+	if *waiversPath != "" {
+		waivers, err := LoadWaivers(*waiversPath)
+		if err != nil {
+			fmt.Printf("Error loading waivers: %v\n", err)
+			os.Exit(1)
+		}
+		expiredWaivers = ApplyWaivers(differences, waivers, time.Now())
+	}
+
+	// 5. Report Results
 	fmt.Println("---")
+	for _, w := range expiredWaivers {
+		fmt.Printf("⌛ EXPIRED WAIVER: %s\n", w.String())
+	}
 	if len(differences) == 0 {
 		fmt.Println("✅ PASS: Actual state matches the blueprint.")
 		os.Exit(0)
-	} else {
-		fmt.Printf("❌ FAIL: Found %d difference(s).\n", len(differences))
-		for _, diff := range differences {
-			fmt.Println(diff.String())
+	}
+
+	blocking := 0
+	for _, diff := range differences {
+		if diff.IsBlocking() {
+			blocking++
 		}
+	}
+	// Only unwaived errors decide the exit code; info, warn and waived differences are reported only.
+	if blocking == 0 {
+		fmt.Printf("✅ PASS: Found %d non-blocking difference(s).\n", len(differences))
+	} else {
+		fmt.Printf("❌ FAIL: Found %d blocking difference(s) out of %d.\n", blocking, len(differences))
+	}
+	for _, diff := range differences {
+		fmt.Println(diff.String())
+	}
+	if blocking > 0 {
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v3"
+)
+
+// OfflineProvider is implemented by providers that can validate a resource from
+// the files of an IaC change instead of the live cloud API.
+//
+// Kubernetes providers do not need it: in offline mode their clients are backed
+// by the rendered manifests (see newKubernetesClientset and newDynamicClient).
+type OfflineProvider interface {
+	ValidateOffline(res Resource) ([]Difference, error)
+}
+
+// TerragruntMap maps blueprint resources to the terragrunt file that creates them.
+//
+//	root: ../live/dreamhaven/justice/stage   # optional, relative to the map file
+//	resources:
+//	  rds-dreamhaven-justice-stage-justice-lobby:
+//	    path: rds/justice-lobby/terragrunt.hcl
+//	    inputs:                               # optional, overrides provider defaults
+//	      instanceClass: instance_class
+type TerragruntMap struct {
+	Root      string                           `yaml:"root,omitempty"`
+	Resources map[string]TerragruntMapResource `yaml:"resources"`
+}
+
+// TerragruntMapResource points one blueprint resource at its terragrunt file.
+type TerragruntMapResource struct {
+	Path   string            `yaml:"path"`
+	Inputs map[string]string `yaml:"inputs,omitempty"`
+}
+
+// terragruntMap is loaded once from --terragrunt-map.
+var terragruntMap *TerragruntMap
+
+// LoadTerragruntMap reads and parses a terragrunt path mapping file. Relative
+// paths inside it are resolved against the directory of the file.
+func LoadTerragruntMap(path string) (*TerragruntMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read terragrunt map %s: %w", path, err)
+	}
+
+	var tm TerragruntMap
+	if err := yaml.Unmarshal(data, &tm); err != nil {
+		return nil, fmt.Errorf("could not parse terragrunt map YAML %s: %w", path, err)
+	}
+	if !filepath.IsAbs(tm.Root) {
+		tm.Root = filepath.Join(filepath.Dir(path), tm.Root)
+	}
+	return &tm, nil
+}
+
+// terragruntInput is the terragrunt input of a spec key and the attributes the live
+// provider reports for it, e.g. the Writer and Reader Instance Class of Aurora.
+type terragruntInput struct {
+	name       string
+	attributes []string
+}
+
+// validateTerragruntInputs compares the blueprint spec of res against the `inputs`
+// of its mapped terragrunt file. defaultInputs maps spec keys to inputs, the input
+// names can be overridden per resource in the terragrunt map.
+func validateTerragruntInputs(res Resource, providerName string, defaultInputs map[string]terragruntInput) ([]Difference, error) {
+	var diffs []Difference
+
+	if terragruntMap == nil {
+		return nil, fmt.Errorf("--terragrunt-map is required to validate AWS resources in offline mode")
+	}
+	mapping, ok := terragruntMap.Resources[res.Name]
+	if !ok {
+		// Only the resources touched by the change are expected to be mapped.
+		fmt.Printf("⏭️  Skipping %s: no terragrunt file mapped\n", res.Name)
+		return diffs, nil
+	}
+
+	path := mapping.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(terragruntMap.Root, path)
+	}
+	inputs, err := readTerragruntInputs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	inputNames := make(map[string]string, len(defaultInputs))
+	for specKey, input := range defaultInputs {
+		inputNames[specKey] = input.name
+	}
+	for specKey, inputName := range mapping.Inputs {
+		inputNames[specKey] = inputName
+	}
+
+	specKeys := make([]string, 0, len(inputNames))
+	for specKey := range inputNames {
+		specKeys = append(specKeys, specKey)
+	}
+	sort.Strings(specKeys) // Keep the report order stable.
+
+	for _, specKey := range specKeys {
+		expected, ok := res.Spec[specKey]
+		if !ok {
+			continue
+		}
+		inputName := inputNames[specKey]
+		actual, exists := inputs[inputName]
+		if !exists || !inputValueEqual(expected, actual) {
+			var actualVal interface{} = "Not Set"
+			if exists {
+				actualVal = actual
+			}
+			// a spec key mapped only in the terragrunt map is reported by its name
+			attributes := defaultInputs[specKey].attributes
+			if len(attributes) == 0 {
+				attributes = []string{specKey}
+			}
+			for _, attribute := range attributes {
+				diffs = append(diffs, Difference{
+					ResourceName: res.Name,
+					Provider:     providerName,
+					Attribute:    attribute,
+					Expected:     expected,
+					Actual:       actualVal,
+					Input:        inputName,
+				})
+			}
+		}
+	}
+
+	return diffs, nil
+}
+
+// readTerragruntInputs returns the statically known values of the `inputs` object
+// in a terragrunt file. References to `local.*` are resolved from the file's own
+// locals block; anything that needs terragrunt itself (dependencies, functions,
+// parent includes) is reported as unresolved.
+func readTerragruntInputs(path string) (map[string]interface{}, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read terragrunt file %s: %w", path, err)
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("could not parse terragrunt file %s: %s", path, diags.Error())
+	}
+	body := file.Body.(*hclsyntax.Body)
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{"local": cty.ObjectVal(evalTerragruntLocals(body))},
+	}
+
+	attr, ok := body.Attributes["inputs"]
+	if !ok {
+		return nil, fmt.Errorf("no inputs found in terragrunt file %s", path)
+	}
+	obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil, fmt.Errorf("inputs in terragrunt file %s is not an object", path)
+	}
+
+	inputs := make(map[string]interface{})
+	for _, item := range obj.Items {
+		key, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || key.Type() != cty.String {
+			continue
+		}
+		val, diags := item.ValueExpr.Value(ctx)
+		if diags.HasErrors() || !val.IsWhollyKnown() {
+			inputs[key.AsString()] = "Unresolved expression"
+			continue
+		}
+		inputs[key.AsString()] = ctyToInterface(val)
+	}
+	return inputs, nil
+}
+
+// evalTerragruntLocals evaluates the locals block of a terragrunt file as far as
+// possible. Locals may refer to each other, so evaluation is repeated until no
+// more locals can be resolved.
+func evalTerragruntLocals(body *hclsyntax.Body) map[string]cty.Value {
+	locals := make(map[string]cty.Value)
+	pending := make(map[string]hclsyntax.Expression)
+	for _, block := range body.Blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		for name, attr := range block.Body.Attributes {
+			pending[name] = attr.Expr
+		}
+	}
+
+	for progress := true; progress; {
+		progress = false
+		ctx := &hcl.EvalContext{
+			Variables: map[string]cty.Value{"local": cty.ObjectVal(locals)},
+		}
+		for name, expr := range pending {
+			val, diags := expr.Value(ctx)
+			if diags.HasErrors() || !val.IsWhollyKnown() {
+				continue
+			}
+			locals[name] = val
+			delete(pending, name)
+			progress = true
+		}
+	}
+	return locals
+}
+
+// ctyToInterface converts a known cty value into the plain Go types used by
+// blueprint specs, so both sides can be compared and reported the same way.
+func ctyToInterface(val cty.Value) interface{} {
+	if val.IsNull() {
+		return nil
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString()
+	case ty == cty.Bool:
+		return val.True()
+	case ty == cty.Number:
+		bf := val.AsBigFloat()
+		if bf.IsInt() {
+			i, acc := bf.Int64()
+			if acc == big.Exact {
+				return int(i)
+			}
+		}
+		f, _ := bf.Float64()
+		return f
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		out := []interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			out = append(out, ctyToInterface(v))
+		}
+		return out
+	case ty.IsMapType() || ty.IsObjectType():
+		out := map[string]interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			out[k.AsString()] = ctyToInterface(v)
+		}
+		return out
+	}
+	return fmt.Sprint(val.GoString())
+}
+
+// inputValueEqual compares a blueprint value with a terragrunt input value.
+// Lists are compared as sets and scalars by their string form, so "16" matches 16.
+func inputValueEqual(expected, actual interface{}) bool {
+	if expectedList, ok := toStringSlice(expected); ok {
+		actualItems, ok := actual.([]interface{})
+		if !ok {
+			return false
+		}
+		actualList := make([]string, 0, len(actualItems))
+		for _, item := range actualItems {
+			actualList = append(actualList, fmt.Sprint(item))
+		}
+		return sameStringSet(expectedList, actualList)
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useTerragrunt maps resources to terragrunt files written to a temporary root for
+// the duration of a test.
+func useTerragrunt(t *testing.T, files map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+	tm := &TerragruntMap{Root: root, Resources: map[string]TerragruntMapResource{}}
+	for name, content := range files {
+		path := filepath.Join(name, "terragrunt.hcl")
+		if err := os.MkdirAll(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		tm.Resources[name] = TerragruntMapResource{Path: path}
+	}
+	previous := terragruntMap
+	terragruntMap = tm
+	t.Cleanup(func() { terragruntMap = previous })
+}
+
+func TestValidateOfflineAttributes(t *testing.T) {
+	useTerragrunt(t, map[string]string{
+		"postgres": `inputs = { instance_class = "db.t3.small" }`,
+		"aurora":   `inputs = { instance_class = "db.r6g.large" }`,
+	})
+
+	tests := []struct {
+		name     string
+		provider OfflineProvider
+		resource Resource
+		want     []string
+	}{
+		{
+			name:     "same attribute as the live provider",
+			provider: &AWSRDSPostgreSQLProvider{},
+			resource: Resource{Name: "postgres", Spec: map[string]interface{}{"instanceClass": "db.t3.medium"}},
+			want:     []string{"postgres Instance Class: db.t3.medium != db.t3.small"},
+		},
+		{
+			name:     "one input reported as every live attribute",
+			provider: &AWSRDSAuroraProvisionedProvider{},
+			resource: Resource{Name: "aurora", Spec: map[string]interface{}{"instanceClass": "db.r6g.xlarge"}},
+			want: []string{
+				"aurora Writer Instance Class: db.r6g.xlarge != db.r6g.large",
+				"aurora Reader Instance Class: db.r6g.xlarge != db.r6g.large",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := tt.provider.ValidateOffline(tt.resource)
+			if err != nil {
+				t.Fatalf("ValidateOffline() unexpected error: %v", err)
+			}
+			if got := describeDiffs(diffs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateOffline() differences:\n got: %q\nwant: %q", got, tt.want)
+			}
+			for _, d := range diffs {
+				if d.Input != "instance_class" {
+					t.Errorf("Input = %q, want instance_class", d.Input)
+				}
+			}
+		})
+	}
+}
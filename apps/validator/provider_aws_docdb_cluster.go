@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/docdb"
 	"github.com/aws/aws-sdk-go-v2/service/docdb/types"
 )
@@ -20,7 +19,7 @@ func init() {
 func (p *AWSDocDBClusterProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := loadAWSConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -68,3 +67,10 @@ func (p *AWSDocDBClusterProvider) Validate(res Resource) ([]Difference, error) {
 
 	return diffs, nil
 }
+
+// ValidateOffline validates the DocDB cluster against the inputs of the terragrunt file that creates it.
+func (p *AWSDocDBClusterProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-docdb-cluster", map[string]terragruntInput{
+		"instanceClass": {"instance_class", []string{"Instance Class"}},
+	})
+}
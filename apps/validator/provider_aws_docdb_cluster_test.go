@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestAWSDocDBClusterProvider(t *testing.T) {
+	runProviderTests(t, &AWSDocDBClusterProvider{}, []providerTest{
+		{
+			name:     "one instance drifted",
+			fixture:  "aws-docdb-cluster",
+			resource: Resource{Name: "justice-docdb", Spec: map[string]interface{}{"instanceClass": "db.r6g.large"}},
+			want:     []string{"justice-docdb-2 Instance Class: db.r6g.large != db.t4g.medium"},
+		},
+		{
+			name:     "instance class not in blueprint",
+			fixture:  "aws-docdb-cluster",
+			resource: Resource{Name: "justice-docdb", Spec: map[string]interface{}{}},
+		},
+		{
+			name:     "cluster without instances",
+			fixture:  "aws-docdb-cluster",
+			resource: Resource{Name: "empty-docdb", Spec: map[string]interface{}{"instanceClass": "db.r6g.large"}},
+			wantErr:  "no instances found",
+		},
+	})
+}
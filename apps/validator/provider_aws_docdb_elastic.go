@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/docdbelastic"
 )
 
@@ -19,7 +18,7 @@ func init() {
 func (p *AWSDocDBElasticProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := loadAWSConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -72,6 +71,15 @@ func (p *AWSDocDBElasticProvider) Validate(res Resource) ([]Difference, error) {
 	return diffs, nil
 }
 
+// ValidateOffline validates the DocDB Elastic cluster against the inputs of the terragrunt file that creates it.
+func (p *AWSDocDBElasticProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-docdb-elastic", map[string]terragruntInput{
+		"shardCount":         {"shard_count", []string{"shardCount"}},
+		"shardInstanceCount": {"shard_instance_count", []string{"shardInstanceCount"}},
+		"shardCapacity":      {"shard_capacity", []string{"shardCapacity"}},
+	})
+}
+
 // checkInt32Spec is a helper to compare an expected int value from the blueprint
 // with an actual *int32 value from the AWS SDK.
 func checkInt32Spec(res Resource, key string, actual *int32) []Difference {
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestAWSDocDBElasticProvider(t *testing.T) {
+	runProviderTests(t, &AWSDocDBElasticProvider{}, []providerTest{
+		{
+			name:    "shards match",
+			fixture: "aws-docdb-elastic",
+			resource: Resource{Name: "justice-docdb-elastic", Spec: map[string]interface{}{
+				"shardCount": 2, "shardInstanceCount": 2, "shardCapacity": 4,
+			}},
+		},
+		{
+			name:    "shard count and capacity drifted",
+			fixture: "aws-docdb-elastic",
+			resource: Resource{Name: "justice-docdb-elastic", Spec: map[string]interface{}{
+				"shardCount": 4, "shardInstanceCount": 2, "shardCapacity": 8,
+			}},
+			want: []string{
+				"justice-docdb-elastic shardCount: 4 != 2",
+				"justice-docdb-elastic shardCapacity: 8 != 4",
+			},
+		},
+		{
+			name:     "cluster not found",
+			fixture:  "aws-docdb-elastic",
+			resource: Resource{Name: "missing-docdb-elastic", Spec: map[string]interface{}{"shardCount": 2}},
+			wantErr:  "no DocDB Elastic cluster found",
+		},
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// AWSEKSNodegroupProvider validates AWS EKS managed node groups.
+type AWSEKSNodegroupProvider struct{}
+
+func init() {
+	// Register this provider with its type string.
+	providerRegistry["aws-eks-nodegroup"] = &AWSEKSNodegroupProvider{}
+}
+
+func (p *AWSEKSNodegroupProvider) Validate(res Resource) ([]Difference, error) {
+	var diffs []Difference
+
+	// A node group name is only unique within its cluster, so the cluster is required.
+	clusterName, ok := res.Spec["clusterName"].(string)
+	if !ok || clusterName == "" {
+		return nil, fmt.Errorf("spec.clusterName is required for EKS node group %s", res.Name)
+	}
+
+	cfg, err := loadAWSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := eks.NewFromConfig(cfg)
+
+	input := &eks.DescribeNodegroupInput{
+		ClusterName:   &clusterName,
+		NodegroupName: &res.Name,
+	}
+	output, err := client.DescribeNodegroup(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EKS node group %s in cluster %s: %w", res.Name, clusterName, err)
+	}
+	if output.Nodegroup == nil {
+		return nil, fmt.Errorf("EKS node group %s not found in cluster %s", res.Name, clusterName)
+	}
+	nodegroup := output.Nodegroup
+
+	// Validate Instance Types (order does not matter)
+	if expectedTypes, ok := toStringSlice(res.Spec["instanceTypes"]); ok {
+		if !sameStringSet(expectedTypes, nodegroup.InstanceTypes) {
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "aws-eks-nodegroup",
+				Attribute:    "instanceTypes",
+				Expected:     expectedTypes,
+				Actual:       nodegroup.InstanceTypes,
+			})
+		}
+	}
+
+	// Validate Scaling Config
+	if nodegroup.ScalingConfig != nil {
+		diffs = append(diffs, checkNodegroupSize(res, "minSize", nodegroup.ScalingConfig.MinSize)...)
+		diffs = append(diffs, checkNodegroupSize(res, "maxSize", nodegroup.ScalingConfig.MaxSize)...)
+		diffs = append(diffs, checkNodegroupSize(res, "desiredSize", nodegroup.ScalingConfig.DesiredSize)...)
+	}
+
+	// Validate AMI Type. The SDK returns an enum type, so we convert it to a string for comparison.
+	if expectedAMI, ok := res.Spec["amiType"].(string); ok {
+		actualAMI := string(nodegroup.AmiType)
+		if actualAMI != expectedAMI {
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "aws-eks-nodegroup",
+				Attribute:    "amiType",
+				Expected:     expectedAMI,
+				Actual:       actualAMI,
+			})
+		}
+	}
+
+	// Validate Kubernetes Version
+	if expectedVersion, ok := res.Spec["version"].(string); ok {
+		actualVersion := "Not Set"
+		if nodegroup.Version != nil {
+			actualVersion = *nodegroup.Version
+		}
+		if actualVersion != expectedVersion {
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "aws-eks-nodegroup",
+				Attribute:    "version",
+				Expected:     expectedVersion,
+				Actual:       actualVersion,
+			})
+		}
+	}
+
+	return diffs, nil
+}
+
+// ValidateOffline validates the node group against the inputs of the terragrunt file that creates it.
+func (p *AWSEKSNodegroupProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-eks-nodegroup", map[string]terragruntInput{
+		"instanceTypes": {"instance_types", []string{"instanceTypes"}},
+		"minSize":       {"min_size", []string{"minSize"}},
+		"maxSize":       {"max_size", []string{"maxSize"}},
+		"desiredSize":   {"desired_size", []string{"desiredSize"}},
+		"amiType":       {"ami_type", []string{"amiType"}},
+		"version":       {"cluster_version", []string{"version"}},
+	})
+}
+
+// checkNodegroupSize is a helper to compare an expected int value from the blueprint
+// with one of the *int32 scaling values of a node group.
+func checkNodegroupSize(res Resource, key string, actual *int32) []Difference {
+	var diffs []Difference
+	if expected, ok := res.Spec[key].(int); ok {
+		if actual == nil || *actual != int32(expected) {
+			actualVal := "Not Set"
+			if actual != nil {
+				actualVal = fmt.Sprintf("%d", *actual)
+			}
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "aws-eks-nodegroup",
+				Attribute:    key,
+				Expected:     expected,
+				Actual:       actualVal,
+			})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestAWSEKSNodegroupProvider(t *testing.T) {
+	runProviderTests(t, &AWSEKSNodegroupProvider{}, []providerTest{
+		{
+			name:    "node group matches",
+			fixture: "aws-eks-nodegroup",
+			resource: Resource{Name: "default", Spec: map[string]interface{}{
+				"clusterName":   "justice-eks",
+				"instanceTypes": []interface{}{"m6a.large", "m6i.large"},
+				"minSize":       2,
+				"maxSize":       10,
+				"desiredSize":   3,
+				"amiType":       "AL2023_x86_64_STANDARD",
+				"version":       "1.30",
+			}},
+		},
+		{
+			name:    "scaling and version drifted",
+			fixture: "aws-eks-nodegroup",
+			resource: Resource{Name: "default", Spec: map[string]interface{}{
+				"clusterName": "justice-eks",
+				"maxSize":     20,
+				"version":     "1.31",
+			}},
+			want: []string{
+				"default maxSize: 20 != 10",
+				"default version: 1.31 != 1.30",
+			},
+		},
+		{
+			name:     "cluster name missing",
+			fixture:  "aws-eks-nodegroup",
+			resource: Resource{Name: "default", Spec: map[string]interface{}{}},
+			wantErr:  "spec.clusterName is required",
+		},
+		{
+			name:     "node group not found",
+			fixture:  "aws-eks-nodegroup",
+			resource: Resource{Name: "missing", Spec: map[string]interface{}{"clusterName": "justice-eks"}},
+			wantErr:  "ResourceNotFoundException",
+		},
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/elasticache"
 )
 
@@ -19,7 +18,7 @@ func init() {
 func (p *AWSElastiCacheRedisProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := loadAWSConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -73,3 +72,10 @@ func (p *AWSElastiCacheRedisProvider) Validate(res Resource) ([]Difference, erro
 
 	return diffs, nil
 }
+
+// ValidateOffline validates the replication group against the inputs of the terragrunt file that creates it.
+func (p *AWSElastiCacheRedisProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-elasticache-redis", map[string]terragruntInput{
+		"cacheNodeType": {"node_type", []string{"Cache Node Type"}},
+	})
+}
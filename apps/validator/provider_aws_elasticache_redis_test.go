@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestAWSElastiCacheRedisProvider(t *testing.T) {
+	runProviderTests(t, &AWSElastiCacheRedisProvider{}, []providerTest{
+		{
+			name:     "one member drifted",
+			fixture:  "aws-elasticache-redis",
+			resource: Resource{Name: "justice-redis", Spec: map[string]interface{}{"cacheNodeType": "cache.r6g.large"}},
+			want:     []string{"justice-redis-002 Cache Node Type: cache.r6g.large != cache.t4g.medium"},
+		},
+		{
+			name:     "replication group not found",
+			fixture:  "aws-elasticache-redis",
+			resource: Resource{Name: "missing-redis", Spec: map[string]interface{}{"cacheNodeType": "cache.r6g.large"}},
+			wantErr:  "ReplicationGroupNotFoundFault",
+		},
+	})
+}
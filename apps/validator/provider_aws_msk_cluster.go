@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/kafka"
 )
 
@@ -19,7 +18,7 @@ func init() {
 func (p *AWSMSKProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := loadAWSConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -74,3 +73,10 @@ func (p *AWSMSKProvider) Validate(res Resource) ([]Difference, error) {
 
 	return diffs, nil
 }
+
+// ValidateOffline validates the MSK cluster against the inputs of the terragrunt file that creates it.
+func (p *AWSMSKProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-msk-cluster", map[string]terragruntInput{
+		"instanceType": {"broker_node_instance_type", []string{"Broker Instance Type"}},
+	})
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestAWSMSKProvider(t *testing.T) {
+	runProviderTests(t, &AWSMSKProvider{}, []providerTest{
+		{
+			name:     "instance type matches",
+			fixture:  "aws-msk-cluster",
+			resource: Resource{Name: "justice-msk", Spec: map[string]interface{}{"instanceType": "kafka.m5.large"}},
+		},
+		{
+			name:     "instance type drifted",
+			fixture:  "aws-msk-cluster",
+			resource: Resource{Name: "justice-msk", Spec: map[string]interface{}{"instanceType": "kafka.m5.xlarge"}},
+			want:     []string{"justice-msk Broker Instance Type: kafka.m5.xlarge != kafka.m5.large"},
+		},
+		{
+			name:     "cluster not found",
+			fixture:  "aws-msk-cluster",
+			resource: Resource{Name: "missing-msk", Spec: map[string]interface{}{"instanceType": "kafka.m5.large"}},
+			wantErr:  "no MSK cluster found",
+		},
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/opensearch"
 )
 
@@ -19,7 +18,7 @@ func init() {
 func (p *AWSOpenSearchDomainProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := loadAWSConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -61,3 +60,10 @@ func (p *AWSOpenSearchDomainProvider) Validate(res Resource) ([]Difference, erro
 
 	return diffs, nil
 }
+
+// ValidateOffline validates the OpenSearch domain against the inputs of the terragrunt file that creates it.
+func (p *AWSOpenSearchDomainProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-opensearch-domain", map[string]terragruntInput{
+		"instanceType": {"instance_type", []string{"Instance Type"}},
+	})
+}
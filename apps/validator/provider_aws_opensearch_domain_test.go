@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestAWSOpenSearchDomainProvider(t *testing.T) {
+	runProviderTests(t, &AWSOpenSearchDomainProvider{}, []providerTest{
+		{
+			name:     "instance type matches",
+			fixture:  "aws-opensearch-domain",
+			resource: Resource{Name: "justice-search", Spec: map[string]interface{}{"instanceType": "r6g.large.search"}},
+		},
+		{
+			name:     "instance type drifted",
+			fixture:  "aws-opensearch-domain",
+			resource: Resource{Name: "justice-search", Spec: map[string]interface{}{"instanceType": "r6g.xlarge.search"}},
+			want:     []string{"justice-search Instance Type: r6g.xlarge.search != r6g.large.search"},
+		},
+		{
+			name:     "domain not found",
+			fixture:  "aws-opensearch-domain",
+			resource: Resource{Name: "missing-search", Spec: map[string]interface{}{"instanceType": "r6g.large.search"}},
+			wantErr:  "ResourceNotFoundException",
+		},
+	})
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 )
@@ -21,7 +20,7 @@ func init() {
 func (p *AWSRDSAuroraProvisionedProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := loadAWSConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -103,3 +102,10 @@ func (p *AWSRDSAuroraProvisionedProvider) Validate(res Resource) ([]Difference,
 
 	return diffs, nil
 }
+
+// ValidateOffline validates the Aurora cluster against the inputs of the terragrunt file that creates it.
+func (p *AWSRDSAuroraProvisionedProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-rds-aurora-provisioned", map[string]terragruntInput{
+		"instanceClass": {"instance_class", []string{"Writer Instance Class", "Reader Instance Class"}},
+	})
+}
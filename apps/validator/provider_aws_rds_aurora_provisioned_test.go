@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestAWSRDSAuroraProvisionedProvider(t *testing.T) {
+	runProviderTests(t, &AWSRDSAuroraProvisionedProvider{}, []providerTest{
+		{
+			name:     "writer matches, reader drifted",
+			fixture:  "aws-rds-aurora-provisioned",
+			resource: Resource{Name: "justice-aurora", Spec: map[string]interface{}{"instanceClass": "db.r6g.large"}},
+			want:     []string{"justice-aurora-2 Reader Instance Class: db.r6g.large != db.r6g.xlarge"},
+		},
+		{
+			name:     "writer drifted, reader matches",
+			fixture:  "aws-rds-aurora-provisioned",
+			resource: Resource{Name: "justice-aurora", Spec: map[string]interface{}{"instanceClass": "db.r6g.xlarge"}},
+			want:     []string{"justice-aurora-1 Writer Instance Class: db.r6g.xlarge != db.r6g.large"},
+		},
+		{
+			name:     "cluster not found",
+			fixture:  "aws-rds-aurora-provisioned",
+			resource: Resource{Name: "missing-aurora", Spec: map[string]interface{}{"instanceClass": "db.r6g.large"}},
+			wantErr:  "DBClusterNotFoundFault",
+		},
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 )
 
@@ -18,7 +17,7 @@ func init() {
 func (p *AWSRDSPostgreSQLProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := loadAWSConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -52,3 +51,10 @@ func (p *AWSRDSPostgreSQLProvider) Validate(res Resource) ([]Difference, error)
 
 	return diffs, nil
 }
+
+// ValidateOffline validates the RDS instance against the inputs of the terragrunt file that creates it.
+func (p *AWSRDSPostgreSQLProvider) ValidateOffline(res Resource) ([]Difference, error) {
+	return validateTerragruntInputs(res, "aws-rds-postgresql", map[string]terragruntInput{
+		"instanceClass": {"instance_class", []string{"Instance Class"}},
+	})
+}
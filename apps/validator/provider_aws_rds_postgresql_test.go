@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestAWSRDSPostgreSQLProvider(t *testing.T) {
+	runProviderTests(t, &AWSRDSPostgreSQLProvider{}, []providerTest{
+		{
+			name:     "instance class matches",
+			fixture:  "aws-rds-postgresql",
+			resource: Resource{Name: "justice-db", Spec: map[string]interface{}{"instanceClass": "db.t4g.medium"}},
+		},
+		{
+			name:     "instance class drifted",
+			fixture:  "aws-rds-postgresql",
+			resource: Resource{Name: "justice-db", Spec: map[string]interface{}{"instanceClass": "db.r6g.large"}},
+			want:     []string{"justice-db Instance Class: db.r6g.large != db.t4g.medium"},
+		},
+		{
+			name:     "instance not found",
+			fixture:  "aws-rds-postgresql",
+			resource: Resource{Name: "missing-db", Spec: map[string]interface{}{"instanceClass": "db.t4g.medium"}},
+			wantErr:  "DBInstanceNotFound",
+		},
+	})
+}
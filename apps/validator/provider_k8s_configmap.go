@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubernetesConfigMapProvider validates keys and values of Kubernetes ConfigMaps,
+// e.g. the tier settings stored in cluster-variables.
+type KubernetesConfigMapProvider struct{}
+
+func init() {
+	providerRegistry["k8s-configmap"] = &KubernetesConfigMapProvider{}
+}
+
+func (p *KubernetesConfigMapProvider) Validate(res Resource) ([]Difference, error) {
+	var diffs []Difference
+
+	clientset, err := newKubernetesClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps(res.Namespace).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap %s in namespace %s: %w", res.Name, res.Namespace, err)
+	}
+
+	// Validate that keys exist, regardless of their value.
+	if expectedKeys, ok := toStringSlice(res.Spec["keys"]); ok {
+		for _, key := range expectedKeys {
+			if _, exists := configMap.Data[key]; !exists {
+				diffs = append(diffs, Difference{
+					ResourceName: res.Name,
+					Provider:     "k8s-configmap",
+					Attribute:    fmt.Sprintf("data.%s", key),
+					Expected:     "Set",
+					Actual:       "Not Set",
+				})
+			}
+		}
+	}
+
+	// Validate exact values. Blueprint values may be parsed as numbers or booleans
+	// by YAML, while ConfigMap data is always a string.
+	if expectedData, ok := res.Spec["data"].(map[string]interface{}); ok {
+		keys := make([]string, 0, len(expectedData))
+		for key := range expectedData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // Keep the report order stable.
+
+		for _, key := range keys {
+			expectedVal := fmt.Sprint(expectedData[key])
+			actualVal, exists := configMap.Data[key]
+			if !exists || actualVal != expectedVal {
+				actualValForReport := "Not Set"
+				if exists {
+					actualValForReport = actualVal
+				}
+				diffs = append(diffs, Difference{
+					ResourceName: res.Name,
+					Provider:     "k8s-configmap",
+					Attribute:    fmt.Sprintf("data.%s", key),
+					Expected:     expectedVal,
+					Actual:       actualValForReport,
+				})
+			}
+		}
+	}
+
+	return diffs, nil
+}
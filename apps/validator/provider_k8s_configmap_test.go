@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestKubernetesConfigMapProvider(t *testing.T) {
+	runProviderTests(t, &KubernetesConfigMapProvider{}, []providerTest{
+		{
+			name:    "keys and values match",
+			fixture: "k8s-configmap",
+			resource: Resource{Name: "cluster-variables", Namespace: "justice", Spec: map[string]interface{}{
+				"keys": []interface{}{"AWS_ACCOUNT_ID", "AWS_REGION"},
+				// YAML parses the tier as a number; ConfigMap data is always a string.
+				"data": map[string]interface{}{"CUSTOMER_NAME": "dreamhaven", "TIER": 100},
+			}},
+		},
+		{
+			name:    "missing key and drifted value",
+			fixture: "k8s-configmap",
+			resource: Resource{Name: "cluster-variables", Namespace: "justice", Spec: map[string]interface{}{
+				"keys": []interface{}{"AWS_ACCOUNT_ID", "CLUSTER_NAME"},
+				"data": map[string]interface{}{"ENVIRONMENT_NAME": "stage", "CUSTOMER_NAME": "dreamhaven"},
+			}},
+			want: []string{
+				"cluster-variables data.CLUSTER_NAME: Set != Not Set",
+				"cluster-variables data.ENVIRONMENT_NAME: stage != dev",
+			},
+		},
+		{
+			name:     "configmap not found",
+			fixture:  "k8s-configmap",
+			resource: Resource{Name: "missing", Namespace: "justice", Spec: map[string]interface{}{"keys": []interface{}{"AWS_REGION"}}},
+			wantErr:  "not found",
+		},
+	})
+}
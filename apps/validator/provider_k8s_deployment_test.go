@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestKubernetesDeploymentProvider(t *testing.T) {
+	runProviderTests(t, &KubernetesDeploymentProvider{}, []providerTest{
+		{
+			name:    "deployment matches",
+			fixture: "k8s-deployment",
+			resource: Resource{Name: "justice-iam-service", Namespace: "justice", Spec: map[string]interface{}{
+				"replicas": 2,
+				"resources": map[string]interface{}{
+					"limits":   map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+					"requests": map[string]interface{}{"cpu": "250m", "memory": "512Mi"},
+				},
+			}},
+		},
+		{
+			name:    "replicas and requests drifted",
+			fixture: "k8s-deployment",
+			resource: Resource{Name: "justice-iam-service", Namespace: "justice", Spec: map[string]interface{}{
+				"replicas": 3,
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+				},
+			}},
+			want: []string{
+				"justice-iam-service Replicas: 3 != 2",
+				"justice-iam-service resources.requests.cpu: 500m != 250m",
+			},
+		},
+		{
+			name:     "deployment not found",
+			fixture:  "k8s-deployment",
+			resource: Resource{Name: "justice-iam-service", Namespace: "other", Spec: map[string]interface{}{"replicas": 2}},
+			wantErr:  "not found",
+		},
+	})
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// KubernetesFluxKustomizationProvider validates Flux Kustomizations, i.e. which
+// version of a platform component is applied and whether it is healthy.
+type KubernetesFluxKustomizationProvider struct{}
+
+func init() {
+	providerRegistry["k8s-flux-kustomization"] = &KubernetesFluxKustomizationProvider{}
+}
+
+// kustomizationVersions are the Flux Kustomization API versions, newest first.
+// Clusters and manifests on older Flux releases only serve v1beta2 or v1beta1.
+var kustomizationVersions = []string{"v1", "v1beta2", "v1beta1"}
+
+var digitRegexp = regexp.MustCompile(`\d`)
+
+func (p *KubernetesFluxKustomizationProvider) Validate(res Resource) ([]Difference, error) {
+	var diffs []Difference
+
+	client, err := newDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ks, err := getKustomization(client, res.Namespace, res.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kustomization %s in namespace %s: %w", res.Name, res.Namespace, err)
+	}
+
+	// Version and patch are derived from spec.path, e.g. ./manifests/platform/linkerd/2.14.10/hotfix
+	ksPath, _, _ := unstructured.NestedString(ks.Object, "spec", "path")
+	if expectedVersion, ok := res.Spec["version"].(string); ok {
+		actualVersion := parseKustomizationVersion(ksPath)
+		if actualVersion != expectedVersion {
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "k8s-flux-kustomization",
+				Attribute:    "version",
+				Expected:     expectedVersion,
+				Actual:       actualVersion,
+			})
+		}
+	}
+	if expectedPatch, ok := res.Spec["patch"].(string); ok {
+		actualPatch := parseKustomizationPatch(ksPath)
+		if actualPatch != expectedPatch {
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "k8s-flux-kustomization",
+				Attribute:    "patch",
+				Expected:     expectedPatch,
+				Actual:       actualPatch,
+			})
+		}
+	}
+
+	// Validate Ready condition. Rendered manifests carry no status, so this is only checked live.
+	if expectedReady, ok := res.Spec["ready"].(bool); ok && !offlineMode {
+		actualReady := "Unknown"
+		conditions, _, _ := unstructured.NestedSlice(ks.Object, "status", "conditions")
+		for _, condIntf := range conditions {
+			cond, ok := condIntf.(map[string]interface{})
+			if ok && cond["type"] == "Ready" {
+				actualReady = fmt.Sprint(cond["status"])
+				break
+			}
+		}
+		if actualReady != string(boolToCondition(expectedReady)) {
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "k8s-flux-kustomization",
+				Attribute:    "ready",
+				Expected:     expectedReady,
+				Actual:       actualReady,
+			})
+		}
+	}
+
+	// Validate suspend state. A missing field means the Kustomization is not suspended.
+	if expectedSuspend, ok := res.Spec["suspend"].(bool); ok {
+		actualSuspend, _, _ := unstructured.NestedBool(ks.Object, "spec", "suspend")
+		if actualSuspend != expectedSuspend {
+			diffs = append(diffs, Difference{
+				ResourceName: res.Name,
+				Provider:     "k8s-flux-kustomization",
+				Attribute:    "suspend",
+				Expected:     expectedSuspend,
+				Actual:       actualSuspend,
+			})
+		}
+	}
+
+	return diffs, nil
+}
+
+// getKustomization reads a Kustomization in the first API version that has it. A
+// version the cluster does not serve is reported as not found, like a missing object.
+func getKustomization(client dynamic.Interface, namespace, name string) (*unstructured.Unstructured, error) {
+	var firstErr error
+	for _, version := range kustomizationVersions {
+		gvr := schema.GroupVersionResource{Group: "kustomize.toolkit.fluxcd.io", Version: version, Resource: "kustomizations"}
+		ks, err := client.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err == nil {
+			return ks, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// parseKustomizationVersion returns the version segment of a Kustomization path.
+// The version is the last path segment containing a digit; if the last segment
+// has none it is a patch directory and the version is its parent.
+func parseKustomizationVersion(ksPath string) string {
+	base := path.Base(ksPath)
+	if digitRegexp.MatchString(base) {
+		return base
+	}
+	return path.Base(path.Dir(ksPath))
+}
+
+// parseKustomizationPatch returns the patch segment of a Kustomization path,
+// or "base" if the path points directly at a version directory.
+func parseKustomizationPatch(ksPath string) string {
+	base := path.Base(ksPath)
+	if digitRegexp.MatchString(base) {
+		return "base"
+	}
+	return base
+}
+
+// boolToCondition maps a boolean to the string form used in Kubernetes conditions.
+func boolToCondition(b bool) metav1.ConditionStatus {
+	if b {
+		return metav1.ConditionTrue
+	}
+	return metav1.ConditionFalse
+}
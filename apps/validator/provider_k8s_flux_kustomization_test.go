@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestKubernetesFluxKustomizationProvider(t *testing.T) {
+	runProviderTests(t, &KubernetesFluxKustomizationProvider{}, []providerTest{
+		{
+			name:    "patched kustomization matches",
+			fixture: "k8s-flux-kustomization",
+			resource: Resource{Name: "linkerd", Namespace: "flux-system", Spec: map[string]interface{}{
+				"version": "2.14.10",
+				"patch":   "hotfix",
+				"ready":   true,
+				"suspend": false,
+			}},
+		},
+		{
+			name:    "suspended and not ready",
+			fixture: "k8s-flux-kustomization",
+			resource: Resource{Name: "karpenter", Namespace: "flux-system", Spec: map[string]interface{}{
+				"version": "v1.0.6",
+				"patch":   "base",
+				"ready":   true,
+				"suspend": false,
+			}},
+			want: []string{
+				"karpenter ready: true != False",
+				"karpenter suspend: false != true",
+			},
+		},
+		{
+			name:    "version drifted",
+			fixture: "k8s-flux-kustomization",
+			resource: Resource{Name: "linkerd", Namespace: "flux-system", Spec: map[string]interface{}{
+				"version": "2.15.0",
+				"patch":   "base",
+			}},
+			want: []string{
+				"linkerd version: 2.15.0 != 2.14.10",
+				"linkerd patch: base != hotfix",
+			},
+		},
+		{
+			name:    "v1beta2 kustomization",
+			fixture: "k8s-flux-kustomization-v1beta2",
+			resource: Resource{Name: "linkerd", Namespace: "flux-system", Spec: map[string]interface{}{
+				"version": "2.14.10",
+				"patch":   "base",
+				"ready":   true,
+			}},
+			want: []string{
+				"linkerd patch: base != hotfix",
+			},
+		},
+		{
+			name:     "missing kustomization",
+			fixture:  "k8s-flux-kustomization-v1beta2",
+			resource: Resource{Name: "karpenter", Namespace: "flux-system", Spec: map[string]interface{}{"version": "v1.0.6"}},
+			wantErr:  `"karpenter" not found`,
+		},
+	})
+}
@@ -6,8 +6,6 @@ import (
 
 	v2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 // KubernetesHPAProvider validates Kubernetes HPA (v2) resources.
@@ -20,13 +18,9 @@ func init() {
 func (p *KubernetesHPAProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	clientset, err := newKubernetesClientset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
-	}
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+		return nil, err
 	}
 
 	// Use the autoscaling/v2 API group for modern HPA specs
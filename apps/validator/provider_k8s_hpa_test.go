@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestKubernetesHPAProvider(t *testing.T) {
+	cpuMetric := map[string]interface{}{
+		"type": "Resource",
+		"resource": map[string]interface{}{
+			"name":   "cpu",
+			"target": map[string]interface{}{"type": "Utilization", "averageUtilization": 50},
+		},
+	}
+	memoryMetric := map[string]interface{}{
+		"type": "Resource",
+		"resource": map[string]interface{}{
+			"name":   "memory",
+			"target": map[string]interface{}{"type": "Utilization", "averageUtilization": 70},
+		},
+	}
+
+	runProviderTests(t, &KubernetesHPAProvider{}, []providerTest{
+		{
+			name:    "replicas match",
+			fixture: "k8s-hpa",
+			resource: Resource{Name: "justice-iam-service", Namespace: "justice", Spec: map[string]interface{}{
+				"minReplicas": 2,
+				"maxReplicas": 10,
+			}},
+		},
+		{
+			name:    "max replicas and memory target drifted",
+			fixture: "k8s-hpa",
+			resource: Resource{Name: "justice-iam-service", Namespace: "justice", Spec: map[string]interface{}{
+				"maxReplicas": 20,
+				"metrics":     []interface{}{cpuMetric, memoryMetric},
+			}},
+			want: []string{
+				"justice-iam-service maxReplicas: 20 != 10",
+				"justice-iam-service metrics[1].resource.target.averageUtilization: 70 != 80",
+			},
+		},
+		{
+			name:    "metric count drifted",
+			fixture: "k8s-hpa",
+			resource: Resource{Name: "justice-iam-service", Namespace: "justice", Spec: map[string]interface{}{
+				"metrics": []interface{}{cpuMetric},
+			}},
+			want: []string{"justice-iam-service metrics.count: 1 != 2"},
+		},
+	})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KubernetesKarpenterNodePoolProvider validates Karpenter NodePools (karpenter.sh/v1).
+type KubernetesKarpenterNodePoolProvider struct{}
+
+func init() {
+	providerRegistry["k8s-karpenter-nodepool"] = &KubernetesKarpenterNodePoolProvider{}
+}
+
+// NodePools are a CRD, so they are read through the dynamic client.
+var nodePoolGVR = schema.GroupVersionResource{Group: "karpenter.sh", Version: "v1", Resource: "nodepools"}
+
+// Well-known requirement keys used by Karpenter's AWS provider.
+const (
+	instanceFamilyRequirement = "karpenter.k8s.aws/instance-family"
+	instanceSizeRequirement   = "karpenter.k8s.aws/instance-size"
+)
+
+func (p *KubernetesKarpenterNodePoolProvider) Validate(res Resource) ([]Difference, error) {
+	var diffs []Difference
+
+	client, err := newDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// NodePools are cluster-scoped, so the resource namespace is ignored.
+	nodePool, err := client.Resource(nodePoolGVR).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Karpenter NodePool %s: %w", res.Name, err)
+	}
+
+	// Validate allowed instance families and sizes from the template requirements.
+	requirements, _, _ := unstructured.NestedSlice(nodePool.Object, "spec", "template", "spec", "requirements")
+	if expected, ok := toStringSlice(res.Spec["instanceFamilies"]); ok {
+		diffs = append(diffs, checkRequirement(res.Name, "instanceFamilies", expected, requirements, instanceFamilyRequirement)...)
+	}
+	if expected, ok := toStringSlice(res.Spec["instanceSizes"]); ok {
+		diffs = append(diffs, checkRequirement(res.Name, "instanceSizes", expected, requirements, instanceSizeRequirement)...)
+	}
+
+	// Validate CPU and memory limits.
+	if expectedLimits, ok := res.Spec["limits"].(map[string]interface{}); ok {
+		actualLimits, _, _ := unstructured.NestedMap(nodePool.Object, "spec", "limits")
+		for _, name := range []string{"cpu", "memory"} {
+			expected, ok := expectedLimits[name]
+			if !ok {
+				continue
+			}
+			diffs = append(diffs, checkQuantity(res.Name, fmt.Sprintf("limits.%s", name), fmt.Sprint(expected), actualLimits[name])...)
+		}
+	}
+
+	// Validate disruption budgets, assuming order is consistent.
+	if expectedDisruption, ok := res.Spec["disruption"].(map[string]interface{}); ok {
+		if expectedBudgets, ok := expectedDisruption["budgets"].([]interface{}); ok {
+			actualBudgets, _, _ := unstructured.NestedSlice(nodePool.Object, "spec", "disruption", "budgets")
+			if len(expectedBudgets) != len(actualBudgets) {
+				diffs = append(diffs, Difference{
+					ResourceName: res.Name,
+					Provider:     "k8s-karpenter-nodepool",
+					Attribute:    "disruption.budgets.count",
+					Expected:     len(expectedBudgets),
+					Actual:       len(actualBudgets),
+				})
+			} else {
+				for i, expectedBudgetIntf := range expectedBudgets {
+					expectedBudget, _ := expectedBudgetIntf.(map[string]interface{})
+					actualBudget, _ := actualBudgets[i].(map[string]interface{})
+					diffs = append(diffs, validateDisruptionBudget(res.Name, i, expectedBudget, actualBudget)...)
+				}
+			}
+		}
+	}
+
+	return diffs, nil
+}
+
+// checkRequirement compares the expected values against the "In" requirement with the given key.
+func checkRequirement(resName, attribute string, expected []string, requirements []interface{}, key string) []Difference {
+	var diffs []Difference
+	var actual []string
+	found := false
+	for _, reqIntf := range requirements {
+		req, ok := reqIntf.(map[string]interface{})
+		if !ok || req["key"] != key || req["operator"] != "In" {
+			continue
+		}
+		actual, _, _ = unstructured.NestedStringSlice(req, "values")
+		found = true
+		break
+	}
+
+	if !found || !sameStringSet(expected, actual) {
+		var actualVal interface{} = "Not Set"
+		if found {
+			actualVal = actual
+		}
+		diffs = append(diffs, Difference{
+			ResourceName: resName,
+			Provider:     "k8s-karpenter-nodepool",
+			Attribute:    attribute,
+			Expected:     expected,
+			Actual:       actualVal,
+		})
+	}
+	return diffs
+}
+
+// checkQuantity compares two resource quantities semantically, so "1000" matches "1k".
+func checkQuantity(resName, attribute, expected string, actual interface{}) []Difference {
+	var diffs []Difference
+	actualVal := "Not Set"
+	if actual != nil {
+		actualVal = fmt.Sprint(actual)
+	}
+
+	expectedQty, expectedErr := resource.ParseQuantity(expected)
+	actualQty, actualErr := resource.ParseQuantity(actualVal)
+	if expectedErr != nil || actualErr != nil || expectedQty.Cmp(actualQty) != 0 {
+		diffs = append(diffs, Difference{
+			ResourceName: resName,
+			Provider:     "k8s-karpenter-nodepool",
+			Attribute:    attribute,
+			Expected:     expected,
+			Actual:       actualVal,
+		})
+	}
+	return diffs
+}
+
+// validateDisruptionBudget is a helper to validate a single disruption budget within a NodePool.
+func validateDisruptionBudget(resName string, index int, expected, actual map[string]interface{}) []Difference {
+	var diffs []Difference
+	prefix := fmt.Sprintf("disruption.budgets[%d]", index)
+
+	for _, field := range []string{"nodes", "schedule", "duration"} {
+		expectedVal, ok := expected[field]
+		if !ok {
+			continue
+		}
+		actualVal := "Not Set"
+		if v, exists := actual[field]; exists {
+			actualVal = fmt.Sprint(v)
+		}
+		if fmt.Sprint(expectedVal) != actualVal {
+			diffs = append(diffs, Difference{
+				ResourceName: resName,
+				Provider:     "k8s-karpenter-nodepool",
+				Attribute:    fmt.Sprintf("%s.%s", prefix, field),
+				Expected:     expectedVal,
+				Actual:       actualVal,
+			})
+		}
+	}
+
+	if expectedReasons, ok := toStringSlice(expected["reasons"]); ok {
+		actualReasons, _, _ := unstructured.NestedStringSlice(actual, "reasons")
+		if !sameStringSet(expectedReasons, actualReasons) {
+			diffs = append(diffs, Difference{
+				ResourceName: resName,
+				Provider:     "k8s-karpenter-nodepool",
+				Attribute:    fmt.Sprintf("%s.reasons", prefix),
+				Expected:     expectedReasons,
+				Actual:       actualReasons,
+			})
+		}
+	}
+	return diffs
+}
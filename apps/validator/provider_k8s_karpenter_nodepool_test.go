@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestKubernetesKarpenterNodePoolProvider(t *testing.T) {
+	runProviderTests(t, &KubernetesKarpenterNodePoolProvider{}, []providerTest{
+		{
+			name:    "node pool matches",
+			fixture: "k8s-karpenter-nodepool",
+			resource: Resource{Name: "default", Spec: map[string]interface{}{
+				"instanceFamilies": []interface{}{"m6a", "m6i"},
+				"instanceSizes":    []interface{}{"large", "xlarge"},
+				// Quantities are compared semantically.
+				"limits": map[string]interface{}{"cpu": "100000m", "memory": "400Gi"},
+				"disruption": map[string]interface{}{
+					"budgets": []interface{}{
+						map[string]interface{}{"nodes": "10%"},
+						map[string]interface{}{"nodes": "0", "schedule": "0 9 * * mon-fri", "duration": "8h", "reasons": []interface{}{"Empty", "Underutilized"}},
+					},
+				},
+			}},
+		},
+		{
+			name:    "sizes, limits and budgets drifted",
+			fixture: "k8s-karpenter-nodepool",
+			resource: Resource{Name: "default", Spec: map[string]interface{}{
+				"instanceSizes": []interface{}{"large"},
+				"limits":        map[string]interface{}{"cpu": 200},
+				"disruption": map[string]interface{}{
+					"budgets": []interface{}{
+						map[string]interface{}{"nodes": "20%"},
+						map[string]interface{}{"nodes": "0", "duration": "4h"},
+					},
+				},
+			}},
+			want: []string{
+				"default instanceSizes: [large] != [large xlarge]",
+				"default limits.cpu: 200 != 100",
+				"default disruption.budgets[0].nodes: 20% != 10%",
+				"default disruption.budgets[1].duration: 4h != 8h",
+			},
+		},
+		{
+			name:     "node pool not found",
+			fixture:  "k8s-karpenter-nodepool",
+			resource: Resource{Name: "gpu", Spec: map[string]interface{}{"instanceSizes": []interface{}{"large"}}},
+			wantErr:  "not found",
+		},
+	})
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 // KubernetesMeshProvider validates Linkerd proxy specs via annotations on a Deployment.
@@ -27,13 +25,9 @@ const (
 func (p *KubernetesMeshProvider) Validate(res Resource) ([]Difference, error) {
 	var diffs []Difference
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	clientset, err := newKubernetesClientset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
-	}
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+		return nil, err
 	}
 
 	deployment, err := clientset.AppsV1().Deployments(res.Namespace).Get(context.TODO(), res.Name, metav1.GetOptions{})
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestKubernetesMeshProvider(t *testing.T) {
+	runProviderTests(t, &KubernetesMeshProvider{}, []providerTest{
+		{
+			name:    "proxy limits match",
+			fixture: "k8s-mesh",
+			resource: Resource{Name: "justice-iam-service", Namespace: "justice", Spec: map[string]interface{}{
+				"resources": map[string]interface{}{
+					"limits": map[string]interface{}{"cpu": "100m", "memory": "300Mi"},
+				},
+			}},
+		},
+		{
+			name:    "proxy request missing and drifted",
+			fixture: "k8s-mesh",
+			resource: Resource{Name: "justice-iam-service", Namespace: "justice", Spec: map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"cpu": "50m", "memory": "300Mi"},
+				},
+			}},
+			want: []string{
+				"justice-iam-service resources.requests.cpu: 50m != 25m",
+				"justice-iam-service resources.requests.memory: 300Mi != Not Set",
+			},
+		},
+	})
+}
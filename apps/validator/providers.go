@@ -9,17 +9,37 @@ type Difference struct {
 	Attribute    string
 	Expected     interface{}
 	Actual       interface{}
+	// Input is the terragrunt input an offline difference was read from. Attribute
+	// stays the name the live provider reports, so rules match both modes.
+	Input string
+
+	// Set by RunValidation and ApplyWaivers, not by providers.
+	Severity Severity
+	Waiver   *Waiver
 }
 
 func (d Difference) String() string {
-	return fmt.Sprintf(
-		"  - Resource: %s\n    Provider: %s\n    Attribute: %s\n    Expected: %v\n    Actual: %v",
+	s := fmt.Sprintf(
+		"  - Resource: %s\n    Provider: %s\n    Attribute: %s\n    Expected: %v\n    Actual: %v\n    Severity: %s",
 		d.ResourceName,
 		d.Provider,
 		d.Attribute,
 		d.Expected,
 		d.Actual,
+		d.Severity,
 	)
+	if d.Input != "" {
+		s += fmt.Sprintf("\n    Input: inputs.%s", d.Input)
+	}
+	if d.Waiver != nil {
+		s += fmt.Sprintf("\n    Waived: %s", d.Waiver.String())
+	}
+	return s
+}
+
+// IsBlocking reports whether the difference should fail the run.
+func (d Difference) IsBlocking() bool {
+	return d.Severity == SeverityError && d.Waiver == nil
 }
 
 // Provider is the interface that all resource providers must implement.
@@ -29,3 +49,39 @@ type Provider interface {
 
 // providerRegistry holds all registered provider implementations.
 var providerRegistry = make(map[string]Provider)
+
+// toStringSlice converts a YAML list from a blueprint spec into a []string.
+// It returns false if the value is missing or contains non-string items.
+func toStringSlice(v interface{}) ([]string, bool) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
+
+// sameStringSet reports whether both slices hold the same values, ignoring order.
+func sameStringSet(expected, actual []string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	counts := make(map[string]int, len(expected))
+	for _, s := range expected {
+		counts[s]++
+	}
+	for _, s := range actual {
+		if counts[s] == 0 {
+			return false
+		}
+		counts[s]--
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// providerTest is a single case of a table-driven provider test. The fixture is a
+// directory under testdata, as written by a live run with --record.
+type providerTest struct {
+	name     string
+	fixture  string
+	resource Resource
+	want     []string
+	wantErr  string
+}
+
+// runProviderTests validates every case against its replayed fixture.
+func runProviderTests(t *testing.T, provider Provider, tests []providerTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFixture(t, tt.fixture)
+
+			diffs, err := provider.Validate(tt.resource)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			if got := describeDiffs(diffs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() differences:\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// useFixture switches the providers to replay mode for the duration of a test.
+func useFixture(t *testing.T, fixture string) {
+	t.Helper()
+	previous := replayPath
+	replayPath = filepath.Join("testdata", fixture)
+	t.Cleanup(func() { replayPath = previous })
+}
+
+// describeDiffs renders differences as "<resource> <attribute>: <expected> != <actual>".
+func describeDiffs(diffs []Difference) []string {
+	var out []string
+	for _, d := range diffs {
+		out = append(out, fmt.Sprintf("%s %s: %v != %v", d.ResourceName, d.Attribute, d.Expected, d.Actual))
+	}
+	return out
+}
+
+func TestSameStringSet(t *testing.T) {
+	tests := []struct {
+		name             string
+		expected, actual []string
+		want             bool
+	}{
+		{"same order", []string{"a", "b"}, []string{"a", "b"}, true},
+		{"different order", []string{"a", "b"}, []string{"b", "a"}, true},
+		{"missing value", []string{"a", "b"}, []string{"a"}, false},
+		{"duplicates", []string{"a", "a"}, []string{"a", "b"}, false},
+		{"both empty", nil, []string{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameStringSet(tt.expected, tt.actual); got != tt.want {
+				t.Errorf("sameStringSet(%v, %v) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Fixture file names inside a --record or --replay directory. Kubernetes objects are
+// stored as plain manifests, so a recording can also be used with --manifests.
+const (
+	awsFixtureFile        = "aws.yaml"
+	kubernetesFixtureFile = "kubernetes.yaml"
+)
+
+// Cassette holds the AWS API interactions captured during a recorded run.
+type Cassette struct {
+	Region       string        `yaml:"region"`
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a single AWS API call and the response that was returned.
+type Interaction struct {
+	Request  RecordedRequest  `yaml:"request"`
+	Response RecordedResponse `yaml:"response"`
+}
+
+// RecordedRequest identifies an AWS API call. Query and form bodies are compared
+// regardless of parameter order.
+type RecordedRequest struct {
+	Host   string `yaml:"host"`
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+	Query  string `yaml:"query,omitempty"`
+	Body   string `yaml:"body,omitempty"`
+}
+
+// RecordedResponse is the raw HTTP response that the SDK deserializes.
+type RecordedResponse struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body"`
+}
+
+// Recordings collected during a run with --record, written by SaveRecording.
+var (
+	recordingMu     sync.Mutex
+	awsRecording    = &Cassette{}
+	kubeRecording   []*unstructured.Unstructured
+	kubeRecordedIdx = make(map[string]int)
+)
+
+// replayCassettes caches the loaded AWS fixtures per replay directory.
+var replayCassettes = make(map[string]*Cassette)
+
+// loadAWSConfig returns the AWS config used by all AWS providers. With --replay the
+// config never leaves the process: requests are answered from the recorded fixtures.
+// With --record the real responses are captured as they pass through the SDK.
+func loadAWSConfig() (aws.Config, error) {
+	if replayPath != "" {
+		cassette, err := loadCassette(replayPath)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		return aws.Config{
+			Region:      cassette.Region,
+			Credentials: aws.AnonymousCredentials{},
+			HTTPClient:  &replayClient{cassette: cassette},
+			// A missing fixture is a test failure, not something to retry.
+			Retryer: func() aws.Retryer { return aws.NopRetryer{} },
+		}, nil
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if recordPath != "" {
+		next := cfg.HTTPClient
+		if next == nil {
+			next = awshttp.NewBuildableClient()
+		}
+		cfg.HTTPClient = &recordingClient{next: next}
+		recordingMu.Lock()
+		awsRecording.Region = cfg.Region
+		recordingMu.Unlock()
+	}
+	return cfg, nil
+}
+
+// kubeRestConfig builds the REST config for kubeconfigPath, capturing every object
+// read from the API server when recording.
+func kubeRestConfig() (*rest.Config, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
+	}
+	if recordPath != "" {
+		cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &kubeRecorder{next: rt}
+		})
+	}
+	return cfg, nil
+}
+
+// recordingClient passes requests to the real SDK client and keeps a copy of each exchange.
+type recordingClient struct {
+	next aws.HTTPClient
+}
+
+func (c *recordingClient) Do(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.next.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	headers := make(map[string]string)
+	for name := range resp.Header {
+		// Transport headers would only make the fixtures noisy.
+		if name == "Date" || name == "Content-Length" || name == "Connection" {
+			continue
+		}
+		headers[name] = resp.Header.Get(name)
+	}
+
+	recordingMu.Lock()
+	defer recordingMu.Unlock()
+	awsRecording.Interactions = append(awsRecording.Interactions, Interaction{
+		Request: RecordedRequest{
+			Host:   req.URL.Host,
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  canonicalQuery(req.URL.RawQuery),
+			Body:   canonicalBody(req.Header.Get("Content-Type"), reqBody),
+		},
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: headers,
+			Body:    string(respBody),
+		},
+	})
+	return resp, nil
+}
+
+// replayClient answers SDK requests from a cassette.
+type replayClient struct {
+	cassette *Cassette
+}
+
+func (c *replayClient) Do(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	query := canonicalQuery(req.URL.RawQuery)
+	body := canonicalBody(req.Header.Get("Content-Type"), reqBody)
+
+	for _, interaction := range c.cassette.Interactions {
+		recorded := interaction.Request
+		if recorded.Host != req.URL.Host || recorded.Method != req.Method || recorded.Path != req.URL.Path {
+			continue
+		}
+		if canonicalQuery(recorded.Query) != query || canonicalBody(req.Header.Get("Content-Type"), []byte(recorded.Body)) != body {
+			continue
+		}
+
+		header := make(http.Header)
+		for name, value := range interaction.Response.Headers {
+			header.Set(name, value)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	target := req.URL.Host + req.URL.Path
+	if query != "" {
+		target += "?" + query
+	}
+	return nil, fmt.Errorf("no recorded response for %s %s with body %q", req.Method, target, body)
+}
+
+// kubeRecorder captures the objects returned by successful GET requests to the API server.
+type kubeRecorder struct {
+	next http.RoundTripper
+}
+
+func (r *kubeRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet || resp.StatusCode != http.StatusOK || req.URL.Query().Get("watch") == "true" {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Responses that are not objects (e.g. discovery documents) are not recorded.
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(body); err != nil {
+		return resp, nil
+	}
+	if !obj.IsList() {
+		recordKubeObject(obj)
+		return resp, nil
+	}
+	// List items do not carry their kind, so it is taken from the list.
+	_ = obj.EachListItem(func(item runtime.Object) error {
+		itemObj := item.(*unstructured.Unstructured)
+		if itemObj.GetKind() == "" {
+			itemObj.SetKind(strings.TrimSuffix(obj.GetKind(), "List"))
+		}
+		if itemObj.GetAPIVersion() == "" {
+			itemObj.SetAPIVersion(obj.GetAPIVersion())
+		}
+		recordKubeObject(itemObj)
+		return nil
+	})
+	return resp, nil
+}
+
+// recordKubeObject stores an object once, keeping the latest version that was read.
+func recordKubeObject(obj *unstructured.Unstructured) {
+	obj = obj.DeepCopy()
+	obj.SetManagedFields(nil)
+
+	recordingMu.Lock()
+	defer recordingMu.Unlock()
+	key := fmt.Sprintf("%s/%s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+	if i, exists := kubeRecordedIdx[key]; exists {
+		kubeRecording[i] = obj
+		return
+	}
+	kubeRecordedIdx[key] = len(kubeRecording)
+	kubeRecording = append(kubeRecording, obj)
+}
+
+// SaveRecording writes everything captured with --record into recordPath.
+func SaveRecording() error {
+	recordingMu.Lock()
+	defer recordingMu.Unlock()
+
+	if err := os.MkdirAll(recordPath, 0o755); err != nil {
+		return fmt.Errorf("could not create recording directory %s: %w", recordPath, err)
+	}
+
+	if len(awsRecording.Interactions) > 0 {
+		data, err := yaml.Marshal(awsRecording)
+		if err != nil {
+			return fmt.Errorf("could not encode AWS recording: %w", err)
+		}
+		path := filepath.Join(recordPath, awsFixtureFile)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("could not write AWS recording %s: %w", path, err)
+		}
+	}
+
+	if len(kubeRecording) > 0 {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		for _, obj := range kubeRecording {
+			if err := enc.Encode(obj.Object); err != nil {
+				return fmt.Errorf("could not encode %s %s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("could not encode Kubernetes recording: %w", err)
+		}
+		path := filepath.Join(recordPath, kubernetesFixtureFile)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("could not write Kubernetes recording %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// loadCassette reads the AWS fixtures of a replay directory.
+func loadCassette(dir string) (*Cassette, error) {
+	if cassette, ok := replayCassettes[dir]; ok {
+		return cassette, nil
+	}
+	path := filepath.Join(dir, awsFixtureFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read AWS fixtures %s: %w", path, err)
+	}
+	cassette := &Cassette{}
+	if err := yaml.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("could not parse AWS fixtures %s: %w", path, err)
+	}
+	replayCassettes[dir] = cassette
+	return cassette, nil
+}
+
+// readRequestBody reads the request body and puts it back for the real transport.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// canonicalQuery sorts query parameters so the order they were encoded in does not matter.
+func canonicalQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	return values.Encode()
+}
+
+// canonicalBody sorts form-encoded bodies, as used by the RDS, DocDB and ElastiCache APIs.
+func canonicalBody(contentType string, body []byte) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return canonicalQuery(strings.TrimSpace(string(body)))
+	}
+	return string(body)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// stubHTTPClient answers every request with the same response, standing in for AWS.
+type stubHTTPClient struct {
+	contentType string
+	body        string
+}
+
+func (c stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{c.contentType}},
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+		Request:    req,
+	}, nil
+}
+
+// stubRoundTripper does the same for the Kubernetes API server.
+type stubRoundTripper struct {
+	body string
+}
+
+func (rt stubRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(rt.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	recordPath = dir
+	t.Cleanup(func() {
+		recordPath = ""
+		replayPath = ""
+		awsRecording = &Cassette{}
+		kubeRecording = nil
+		kubeRecordedIdx = make(map[string]int)
+	})
+
+	// Record an RDS call against a stubbed AWS and a Deployment list against a stubbed cluster.
+	awsRecording.Region = "eu-west-1"
+	recordCfg := aws.Config{
+		Region:      "eu-west-1",
+		Credentials: aws.AnonymousCredentials{},
+		HTTPClient: &recordingClient{next: stubHTTPClient{
+			contentType: "text/xml",
+			body:        `<DescribeDBInstancesResponse><DescribeDBInstancesResult><DBInstances><DBInstance><DBInstanceIdentifier>justice-db</DBInstanceIdentifier><DBInstanceClass>db.t4g.medium</DBInstanceClass></DBInstance></DBInstances></DescribeDBInstancesResult></DescribeDBInstancesResponse>`,
+		}},
+	}
+	if _, err := rds.NewFromConfig(recordCfg).DescribeDBInstances(context.TODO(), &rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String("justice-db")}); err != nil {
+		t.Fatalf("recording DescribeDBInstances: %v", err)
+	}
+
+	recorder := &kubeRecorder{next: stubRoundTripper{
+		body: `{"apiVersion":"apps/v1","kind":"DeploymentList","items":[{"metadata":{"name":"justice-iam-service","namespace":"justice","managedFields":[{"manager":"kubectl"}]},"spec":{"replicas":2}}]}`,
+	}}
+	req, _ := http.NewRequest(http.MethodGet, "https://cluster.local/apis/apps/v1/namespaces/justice/deployments", nil)
+	if _, err := recorder.RoundTrip(req); err != nil {
+		t.Fatalf("recording deployment list: %v", err)
+	}
+
+	if err := SaveRecording(); err != nil {
+		t.Fatalf("SaveRecording() error: %v", err)
+	}
+	recordPath = ""
+
+	// Replay both without any network access.
+	replayPath = dir
+
+	cfg, err := loadAWSConfig()
+	if err != nil {
+		t.Fatalf("loadAWSConfig() error: %v", err)
+	}
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("replayed region = %q, want eu-west-1", cfg.Region)
+	}
+	output, err := rds.NewFromConfig(cfg).DescribeDBInstances(context.TODO(), &rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String("justice-db")})
+	if err != nil {
+		t.Fatalf("replaying DescribeDBInstances: %v", err)
+	}
+	if got := aws.ToString(output.DBInstances[0].DBInstanceClass); got != "db.t4g.medium" {
+		t.Errorf("replayed instance class = %q, want db.t4g.medium", got)
+	}
+	if _, err := rds.NewFromConfig(cfg).DescribeDBInstances(context.TODO(), &rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String("other-db")}); err == nil {
+		t.Errorf("replaying an unrecorded call succeeded, want an error")
+	}
+
+	objs, err := decodeManifests(filepath.Join(dir, kubernetesFixtureFile))
+	if err != nil {
+		t.Fatalf("decodeManifests() error: %v", err)
+	}
+	if len(objs) != 1 || objs[0].GetKind() != "Deployment" || objs[0].GetName() != "justice-iam-service" {
+		t.Fatalf("recorded objects = %v, want the justice-iam-service Deployment", objs)
+	}
+	if objs[0].GetManagedFields() != nil {
+		t.Errorf("recorded object kept its managedFields")
+	}
+}
+
+func TestCanonicalBody(t *testing.T) {
+	form := "application/x-www-form-urlencoded; charset=utf-8"
+	a := canonicalBody(form, []byte("Version=2014-10-31&Action=DescribeDBInstances"))
+	b := canonicalBody(form, []byte("Action=DescribeDBInstances&Version=2014-10-31\n"))
+	if a != b {
+		t.Errorf("form bodies with different parameter order differ: %q != %q", a, b)
+	}
+	if got := canonicalBody("application/json", []byte(`{"b":1}`)); got != `{"b":1}` {
+		t.Errorf("JSON body changed to %q", got)
+	}
+}
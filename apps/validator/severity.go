@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity decides how a difference affects the result of a run.
+// Only unwaived differences with SeverityError make the run fail.
+type Severity string
+
+const (
+	SeverityInfo  Severity = "info"
+	SeverityWarn  Severity = "warn"
+	SeverityError Severity = "error"
+)
+
+// parseSeverity validates a severity from a blueprint. An empty value defaults to error.
+func parseSeverity(s string) (Severity, error) {
+	switch Severity(strings.ToLower(s)) {
+	case "":
+		return SeverityError, nil
+	case SeverityInfo:
+		return SeverityInfo, nil
+	case SeverityWarn:
+		return SeverityWarn, nil
+	case SeverityError:
+		return SeverityError, nil
+	}
+	return "", fmt.Errorf("invalid severity %q, must be one of info, warn, error", s)
+}
+
+// validateSeverities checks every severity and ignore rule of a resource.
+func validateSeverities(res Resource) error {
+	if _, err := parseSeverity(res.Severity); err != nil {
+		return fmt.Errorf("resource %s (%s): %w", res.Name, res.Type, err)
+	}
+	for attribute, severity := range res.Severities {
+		if _, err := parseSeverity(severity); err != nil {
+			return fmt.Errorf("resource %s (%s), attribute %s: %w", res.Name, res.Type, attribute, err)
+		}
+	}
+	for _, attribute := range res.Ignore {
+		if attribute == "" {
+			return fmt.Errorf("resource %s (%s): empty ignore rule", res.Name, res.Type)
+		}
+	}
+	return nil
+}
+
+// attributeMatches reports whether a reported attribute is selected by a rule from
+// the blueprint. A rule selects the attribute itself and everything nested below it,
+// so "resources.limits" covers "resources.limits.cpu" and "metrics" covers "metrics[0].type".
+func attributeMatches(rule, attribute string) bool {
+	if strings.EqualFold(rule, attribute) {
+		return true
+	}
+	prefix := strings.ToLower(rule)
+	attr := strings.ToLower(attribute)
+	return strings.HasPrefix(attr, prefix+".") || strings.HasPrefix(attr, prefix+"[")
+}
+
+// isIgnored reports whether the blueprint asks to skip the attribute entirely.
+func isIgnored(res Resource, attribute string) bool {
+	for _, rule := range res.Ignore {
+		if attributeMatches(rule, attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+// severityFor resolves the severity of a difference on the given attribute. The most
+// specific per-attribute rule wins, then the resource severity, then error.
+func severityFor(res Resource, attribute string) Severity {
+	bestRule := ""
+	for rule := range res.Severities {
+		if attributeMatches(rule, attribute) && len(rule) > len(bestRule) {
+			bestRule = rule
+		}
+	}
+	if bestRule != "" {
+		severity, _ := parseSeverity(res.Severities[bestRule])
+		return severity
+	}
+	severity, _ := parseSeverity(res.Severity)
+	return severity
+}
@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func TestAttributeMatches(t *testing.T) {
+	tests := []struct {
+		rule, attribute string
+		want            bool
+	}{
+		{"Instance Class", "Instance Class", true},
+		{"instance class", "Instance Class", true},
+		{"resources.limits", "resources.limits.cpu", true},
+		{"metrics", "metrics[0].type", true},
+		{"resources.limits", "resources.limitsExtra", false},
+		{"resources.limits.cpu", "resources.limits", false},
+		{"Instance", "Instance Class", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.attribute, func(t *testing.T) {
+			if got := attributeMatches(tt.rule, tt.attribute); got != tt.want {
+				t.Errorf("attributeMatches(%q, %q) = %v, want %v", tt.rule, tt.attribute, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverityFor(t *testing.T) {
+	res := Resource{
+		Severity: "warn",
+		Severities: map[string]string{
+			"resources":        "info",
+			"resources.limits": "error",
+			"replicas":         "Info",
+		},
+	}
+	tests := []struct {
+		name      string
+		res       Resource
+		attribute string
+		want      Severity
+	}{
+		{"most specific rule wins", res, "resources.limits.cpu", SeverityError},
+		{"parent rule", res, "resources.requests.cpu", SeverityInfo},
+		{"rule is case insensitive", res, "Replicas", SeverityInfo},
+		{"resource severity", res, "image", SeverityWarn},
+		{"defaults to error", Resource{}, "image", SeverityError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := severityFor(tt.res, tt.attribute); got != tt.want {
+				t.Errorf("severityFor(%q) = %s, want %s", tt.attribute, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	res := Resource{Ignore: []string{"annotations", "Cache Node Type"}}
+	tests := []struct {
+		attribute string
+		want      bool
+	}{
+		{"annotations", true},
+		{"annotations.owner", true},
+		{"cache node type", true},
+		{"labels", false},
+		{"annotationsExtra", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attribute, func(t *testing.T) {
+			if got := isIgnored(res, tt.attribute); got != tt.want {
+				t.Errorf("isIgnored(%q) = %v, want %v", tt.attribute, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSeverities(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     Resource
+		wantErr bool
+	}{
+		{"valid", Resource{Severity: "warn", Severities: map[string]string{"replicas": "info"}, Ignore: []string{"labels"}}, false},
+		{"invalid resource severity", Resource{Severity: "fatal"}, true},
+		{"invalid attribute severity", Resource{Severities: map[string]string{"replicas": "critical"}}, true},
+		{"empty ignore rule", Resource{Ignore: []string{""}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSeverities(tt.res); (err != nil) != tt.wantErr {
+				t.Errorf("validateSeverities() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -13,13 +13,25 @@ func RunValidation(bp *Blueprint) ([]Difference, error) {
 			return nil, fmt.Errorf("no provider found for resource type: %s", resource.Type)
 		}
 
-		diffs, err := provider.Validate(resource)
+		var diffs []Difference
+		var err error
+		if offlineProvider, ok := provider.(OfflineProvider); ok && offlineMode {
+			diffs, err = offlineProvider.ValidateOffline(resource)
+		} else {
+			diffs, err = provider.Validate(resource)
+		}
 		if err != nil {
 			fmt.Printf("❌ Error validating %s: %v\n", resource.Name, err)
 			// For MVP, we stop on error, but could be made more resilient.
 			return nil, err
 		}
-		allDiffs = append(allDiffs, diffs...)
+		for _, diff := range diffs {
+			if isIgnored(resource, diff.Attribute) {
+				continue
+			}
+			diff.Severity = severityFor(resource, diff.Attribute)
+			allDiffs = append(allDiffs, diff)
+		}
 	}
 
 	return allDiffs, nil
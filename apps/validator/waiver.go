@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// waiverDateLayout is the format of the expires field, e.g. 2025-12-31.
+const waiverDateLayout = "2006-01-02"
+
+// WaiverFile lists differences that have been accepted for an environment.
+type WaiverFile struct {
+	Waivers []Waiver `yaml:"waivers"`
+}
+
+// Waiver accepts the differences of a resource until its expiry date. Provider and
+// Attribute narrow the waiver down; when empty they match everything.
+type Waiver struct {
+	Resource  string `yaml:"resource"`
+	Provider  string `yaml:"provider,omitempty"`
+	Attribute string `yaml:"attribute,omitempty"`
+	Owner     string `yaml:"owner"`
+	Reason    string `yaml:"reason,omitempty"`
+	Expires   string `yaml:"expires"`
+
+	expiresAt time.Time
+}
+
+func (w Waiver) String() string {
+	target := w.Resource
+	if w.Provider != "" {
+		target += " (" + w.Provider + ")"
+	}
+	if w.Attribute != "" {
+		target += " " + w.Attribute
+	}
+	return fmt.Sprintf("%s, owner: %s, expires: %s", target, w.Owner, w.Expires)
+}
+
+// LoadWaivers reads and parses a waiver file. Every waiver must name a resource,
+// an owner and an expiry date, so accepted differences cannot be forgotten.
+func LoadWaivers(path string) ([]Waiver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read waiver file %s: %w", path, err)
+	}
+
+	var wf WaiverFile
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("could not parse waiver YAML %s: %w", path, err)
+	}
+
+	for i := range wf.Waivers {
+		w := &wf.Waivers[i]
+		if w.Resource == "" || w.Owner == "" || w.Expires == "" {
+			return nil, fmt.Errorf("waiver #%d in %s must set resource, owner and expires", i+1, path)
+		}
+		// The waiver stays valid for the whole expiry day.
+		expiresAt, err := time.Parse(waiverDateLayout, w.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("waiver #%d in %s has invalid expires %q, expected YYYY-MM-DD", i+1, path, w.Expires)
+		}
+		w.expiresAt = expiresAt.AddDate(0, 0, 1)
+	}
+	return wf.Waivers, nil
+}
+
+// matches reports whether the waiver covers a difference.
+func (w Waiver) matches(d Difference) bool {
+	if w.Resource != d.ResourceName {
+		return false
+	}
+	if w.Provider != "" && w.Provider != d.Provider {
+		return false
+	}
+	return w.Attribute == "" || attributeMatches(w.Attribute, d.Attribute)
+}
+
+// ApplyWaivers marks every difference covered by an active waiver as waived.
+// It returns every expired waiver, whether it still matches a difference or not,
+// so stale waivers get flagged and cleaned up; their differences stay unwaived.
+func ApplyWaivers(diffs []Difference, waivers []Waiver, now time.Time) []Waiver {
+	var active, expired []Waiver
+	for _, w := range waivers {
+		if now.Before(w.expiresAt) {
+			active = append(active, w)
+		} else {
+			expired = append(expired, w)
+		}
+	}
+
+	for i := range diffs {
+		for _, w := range active {
+			if w.matches(diffs[i]) {
+				waiver := w
+				diffs[i].Waiver = &waiver
+				break
+			}
+		}
+	}
+	return expired
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeWaivers(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "waivers.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWaivers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `waivers:
+  - resource: justice-lobby
+    attribute: Instance Class
+    owner: platform
+    expires: 2025-12-31`,
+		},
+		{
+			name: "missing owner",
+			content: `waivers:
+  - resource: justice-lobby
+    expires: 2025-12-31`,
+			wantErr: "must set resource, owner and expires",
+		},
+		{
+			name: "invalid expiry",
+			content: `waivers:
+  - resource: justice-lobby
+    owner: platform
+    expires: 31/12/2025`,
+			wantErr: "expected YYYY-MM-DD",
+		},
+		{
+			name:    "invalid yaml",
+			content: `waivers: [`,
+			wantErr: "could not parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waivers, err := LoadWaivers(writeWaivers(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadWaivers() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadWaivers() unexpected error: %v", err)
+			}
+			// valid for the whole expiry day
+			want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			if len(waivers) != 1 || !waivers[0].expiresAt.Equal(want) {
+				t.Errorf("LoadWaivers() = %+v, want one waiver expiring at %s", waivers, want)
+			}
+		})
+	}
+}
+
+func TestApplyWaivers(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	active := now.Add(24 * time.Hour)
+	expired := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		waivers     []Waiver
+		diffs       []Difference
+		wantWaived  []bool
+		wantExpired []string
+	}{
+		{
+			name:       "attribute prefix",
+			waivers:    []Waiver{{Resource: "app", Attribute: "resources.limits", Owner: "a", expiresAt: active}},
+			diffs:      []Difference{{ResourceName: "app", Attribute: "resources.limits.cpu"}, {ResourceName: "app", Attribute: "resources.requests.cpu"}},
+			wantWaived: []bool{true, false},
+		},
+		{
+			name:       "whole resource of a provider",
+			waivers:    []Waiver{{Resource: "app", Provider: "k8s-deployment", Owner: "a", expiresAt: active}},
+			diffs:      []Difference{{ResourceName: "app", Provider: "k8s-deployment", Attribute: "replicas"}, {ResourceName: "app", Provider: "k8s-hpa", Attribute: "replicas"}},
+			wantWaived: []bool{true, false},
+		},
+		{
+			name:        "expired waiver still matching",
+			waivers:     []Waiver{{Resource: "app", Owner: "a", Expires: "old", expiresAt: expired}},
+			diffs:       []Difference{{ResourceName: "app", Attribute: "replicas"}},
+			wantWaived:  []bool{false},
+			wantExpired: []string{"app"},
+		},
+		{
+			name: "expired waiver without a difference",
+			waivers: []Waiver{
+				{Resource: "removed", Owner: "a", expiresAt: expired},
+				{Resource: "app", Owner: "a", expiresAt: active},
+			},
+			diffs:       []Difference{{ResourceName: "app", Attribute: "replicas"}},
+			wantWaived:  []bool{true},
+			wantExpired: []string{"removed"},
+		},
+		{
+			name:       "unmatched active waiver",
+			waivers:    []Waiver{{Resource: "other", Owner: "a", expiresAt: active}},
+			diffs:      []Difference{{ResourceName: "app", Attribute: "replicas"}},
+			wantWaived: []bool{false},
+		},
+		{
+			name: "active waiver wins over an expired one",
+			waivers: []Waiver{
+				{Resource: "app", Owner: "old", expiresAt: expired},
+				{Resource: "app", Owner: "new", expiresAt: active},
+			},
+			diffs:       []Difference{{ResourceName: "app", Attribute: "replicas"}},
+			wantWaived:  []bool{true},
+			wantExpired: []string{"app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiredWaivers := ApplyWaivers(tt.diffs, tt.waivers, now)
+
+			var waived []bool
+			for _, d := range tt.diffs {
+				waived = append(waived, d.Waiver != nil)
+			}
+			if !reflect.DeepEqual(waived, tt.wantWaived) {
+				t.Errorf("waived = %v, want %v", waived, tt.wantWaived)
+			}
+			var gotExpired []string
+			for _, w := range expiredWaivers {
+				gotExpired = append(gotExpired, w.Resource)
+			}
+			if !reflect.DeepEqual(gotExpired, tt.wantExpired) {
+				t.Errorf("expired = %v, want %v", gotExpired, tt.wantExpired)
+			}
+		})
+	}
+}